@@ -0,0 +1,107 @@
+package main
+
+import (
+	"ay-events-generator/internal/context_merge"
+	"ay-events-generator/internal/dispatcher"
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/partitioner"
+	"ay-events-generator/internal/producer_batcher"
+	"ay-events-generator/internal/sender"
+	"ay-events-generator/internal/serializer"
+	"context"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// partitionWriter записывает сообщения в конкретную партицию Kafka.
+type partitionWriter interface {
+	Write(ctx context.Context, partition int, msgs ...kafka.Message) error
+}
+
+// partitionFlush возвращает flushFn батчера партиции: сериализует сообщения
+// и записывает их в partition через dispatcher.
+func partitionFlush(disp *dispatcher.Dispatcher, pool partitionWriter, ser sender.Serializer, partition int) producer_batcher.Flush[event.PageViewEvent] {
+	return func(messages []producer_batcher.Message[event.PageViewEvent]) {
+		contexts := make([]context.Context, len(messages))
+
+		for i, message := range messages {
+			contexts[i] = message.Ctx
+		}
+
+		// объединенный контекст управляет только отменой записи,
+		// callback каждого сообщения получает его собственный контекст
+		ctxMerged, cancel := context_merge.Merge(contexts...)
+		defer cancel()
+
+		if err := disp.Write(ctxMerged, func(ctx context.Context) error {
+			validMessages := make([]producer_batcher.Message[event.PageViewEvent], 0, len(messages))
+			kafkaMessages := make([]kafka.Message, 0, len(messages))
+
+			for _, message := range messages {
+				var b []byte
+				var err error
+				// надгробие записывается с пустым значением
+				if !event.IsTombstone(message.Ctx) {
+					b, err = ser.Marshal(message.Data)
+				}
+				if err != nil {
+					zap.L().Error(err.Error())
+					if message.Callback != nil {
+						message.Callback(message.Ctx, message.Data, err)
+					}
+					continue
+				}
+
+				kafkaMessage := kafka.Message{
+					Key:     []byte(message.Data.PartitionKey()),
+					Value:   b,
+					Headers: sender.Headers(message.Data),
+				}
+				if b != nil {
+					kafkaMessage.Headers = append(kafkaMessage.Headers, kafka.Header{
+						Key:   serializer.ContentTypeHeader,
+						Value: []byte(ser.ContentType()),
+					})
+				}
+				if seq, ok := partitioner.SequenceFromContext(message.Ctx); ok {
+					kafkaMessage.Headers = append(kafkaMessage.Headers, kafka.Header{
+						Key:   partitioner.SequenceHeader,
+						Value: strconv.AppendUint(nil, seq, 10),
+					})
+				}
+				kafkaMessages = append(kafkaMessages, kafkaMessage)
+				validMessages = append(validMessages, message)
+			}
+
+			if len(kafkaMessages) == 0 {
+				return nil
+			}
+
+			err := pool.Write(ctx, partition, kafkaMessages...)
+			if err != nil {
+				zap.L().Error(err.Error())
+				for _, message := range validMessages {
+					if message.Callback == nil {
+						continue
+					}
+					message.Callback(message.Ctx, message.Data, err)
+				}
+				return err
+			}
+
+			for _, message := range validMessages {
+				if message.Callback == nil {
+					continue
+				}
+				message.Callback(message.Ctx, message.Data, nil)
+			}
+
+			return nil
+		}); err != nil {
+			zap.L().Error(err.Error())
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"ay-events-generator/internal/dispatcher"
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/producer_batcher"
+	"ay-events-generator/internal/sender"
+	"ay-events-generator/internal/serializer"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingPool запоминает сообщения, записанные в партиции.
+type recordingPool struct {
+	mu       sync.Mutex
+	messages map[int][]kafka.Message
+}
+
+func (p *recordingPool) Write(ctx context.Context, partition int, msgs ...kafka.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.messages == nil {
+		p.messages = make(map[int][]kafka.Message)
+	}
+	p.messages[partition] = append(p.messages[partition], msgs...)
+	return nil
+}
+
+// recordingWriter запоминает сообщения, записанные KafkaSender.
+type recordingWriter struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+}
+
+func (w *recordingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *recordingWriter) Close() error {
+	return nil
+}
+
+// failingSerializer не сериализует событие со страницей "bad".
+type failingSerializer struct {
+	serializer.JSON[event.PageViewEvent]
+}
+
+var errMarshal = errors.New("marshal failed")
+
+func (s failingSerializer) Marshal(message event.PageViewEvent) ([]byte, error) {
+	if message.PageID == "bad" {
+		return nil, errMarshal
+	}
+	return s.JSON.Marshal(message)
+}
+
+func TestPartitionFlush_CallbackContext(t *testing.T) {
+	type ctxKey struct{}
+
+	pool := &recordingPool{}
+	flush := partitionFlush(dispatcher.NewDispatcher(), pool, failingSerializer{}, 2)
+
+	type result struct {
+		pageID string
+		ctxID  string
+		err    error
+	}
+	var results []result
+
+	messages := make([]producer_batcher.Message[event.PageViewEvent], 0, 3)
+	for _, id := range []string{"a", "bad", "c"} {
+		messages = append(messages, producer_batcher.Message[event.PageViewEvent]{
+			Ctx:  context.WithValue(t.Context(), ctxKey{}, id),
+			Data: event.PageViewEvent{PageID: id, UserID: "user", ViewDuration: 1},
+			Callback: func(ctx context.Context, message event.PageViewEvent, err error) {
+				v, _ := ctx.Value(ctxKey{}).(string)
+				results = append(results, result{message.PageID, v, err})
+			},
+		})
+	}
+
+	flush(messages)
+
+	// callback каждого сообщения получает его собственный контекст
+	assert.ElementsMatch(t, []result{
+		{"a", "a", nil},
+		{"bad", "bad", errMarshal},
+		{"c", "c", nil},
+	}, results)
+
+	assert.Len(t, pool.messages[2], 2)
+	for _, m := range pool.messages[2] {
+		assert.Equal(t, "user", string(m.Key), "ключ сообщения должен совпадать с PartitionKey")
+	}
+}
+
+func TestPartitionFlush_KeyMatchesSender(t *testing.T) {
+	ev := event.PageViewEvent{PageID: "page", UserID: "user", ViewDuration: 1}
+
+	pool := &recordingPool{}
+	flush := partitionFlush(dispatcher.NewDispatcher(), pool, serializer.JSON[event.PageViewEvent]{}, 0)
+	flush([]producer_batcher.Message[event.PageViewEvent]{{Ctx: t.Context(), Data: ev}})
+
+	w := &recordingWriter{}
+	assert.NoError(t, sender.NewKafkaSender(w).SendSync(t.Context(), ev))
+
+	// партиционированный путь и KafkaSender должны ключевать событие одинаково
+	assert.Len(t, pool.messages[0], 1)
+	assert.Len(t, w.messages, 1)
+	assert.Equal(t, w.messages[0].Key, pool.messages[0][0].Key)
+	assert.Equal(t, ev.PartitionKey(), string(w.messages[0].Key))
+}
@@ -1,19 +1,25 @@
 package main
 
 import (
-	"ay-events-generator/internal/context_merge"
 	"ay-events-generator/internal/dispatcher"
 	"ay-events-generator/internal/event"
 	"ay-events-generator/internal/generator"
 	"ay-events-generator/internal/generator_metrics"
+	"ay-events-generator/internal/partition_pool"
 	"ay-events-generator/internal/partitioner"
+	"ay-events-generator/internal/pipeline"
 	"ay-events-generator/internal/producer_batcher"
 	"ay-events-generator/internal/publisher"
+	"ay-events-generator/internal/sender"
+	"ay-events-generator/internal/serializer"
 	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 )
 
@@ -31,6 +37,8 @@ const (
 	kafkaTopic = "events"
 
 	kafkaPartitionCount = 5
+
+	shutdownTimeout = 30 * time.Second
 )
 
 func main() {
@@ -47,88 +55,28 @@ func main() {
 	}()
 
 	gen := generator.NewEventGenerator()
-	defer gen.Close()
 
 	if err := metrics.CollectEventGenerator(gen); err != nil {
 		zap.L().Fatal(err.Error())
 	}
 
-	var partitionConnections []*kafka.Conn
-	for partition := range kafkaPartitionCount {
-		conn, err := kafka.DialLeader(ctx, "tcp", kafkaAddr, kafkaTopic, partition)
-		if err != nil {
-			zap.L().Fatal(err.Error())
-		}
-		partitionConnections = append(partitionConnections, conn)
+	pool, err := partition_pool.NewPartitionConnPool(ctx, partition_pool.KafkaDialer(kafkaAddr, kafkaTopic), kafkaPartitionCount)
+	if err != nil {
+		zap.L().Fatal(err.Error())
+	}
+
+	if err := metrics.CollectPartitionPool(pool); err != nil {
+		zap.L().Fatal(err.Error())
 	}
-	defer func() {
-		for _, conn := range partitionConnections {
-			if err := conn.Close(); err != nil {
-				zap.L().Error(err.Error())
-			}
-		}
-	}()
 
 	disp := dispatcher.NewDispatcher()
 
+	// один сериализатор для всех партиций, формат задается в одном месте
+	var ser sender.Serializer = serializer.NewJSON[event.PageViewEvent]()
+
 	partitionBatchers := make([]*producer_batcher.Batcher[event.PageViewEvent], kafkaPartitionCount)
 	for partition := range kafkaPartitionCount {
-		bat, err := producer_batcher.NewBatcher[event.PageViewEvent](func(messages []producer_batcher.Message[event.PageViewEvent]) {
-			contexts := make([]context.Context, len(messages))
-
-			for i, message := range messages {
-				contexts[i] = message.Ctx
-			}
-
-			ctxMerged, cancel := context_merge.Merge(contexts...)
-			defer cancel()
-
-			if err := disp.Write(ctxMerged, func(ctx context.Context) error {
-				validMessages := make([]producer_batcher.Message[event.PageViewEvent], 0, len(messages))
-				kafkaMessages := make([]kafka.Message, len(messages))
-
-				for i, message := range messages {
-					b, err := message.Data.Bytes()
-					if err != nil {
-						zap.L().Error(err.Error())
-						if message.Callback != nil {
-							message.Callback(ctx, message.Data, err)
-						}
-						continue
-					}
-
-					kafkaMessages[i] = kafka.Message{
-						Key:   []byte(message.Data.UserID),
-						Value: b,
-					}
-					validMessages = append(validMessages, message)
-				}
-
-				_, err := partitionConnections[partition].WriteMessages(kafkaMessages...)
-				if err != nil {
-					zap.L().Error(err.Error())
-					for _, message := range validMessages {
-						if message.Callback == nil {
-							continue
-						}
-						message.Callback(ctx, message.Data, err)
-					}
-					return err
-				}
-
-				for _, message := range validMessages {
-					if message.Callback == nil {
-						continue
-					}
-					message.Callback(ctxMerged, message.Data, nil)
-				}
-
-				return nil
-			}); err != nil {
-				zap.L().Error(err.Error())
-				return
-			}
-		})
+		bat, err := producer_batcher.NewBatcher[event.PageViewEvent](partitionFlush(disp, pool, ser, partition))
 		if err != nil {
 			zap.L().Fatal(err.Error())
 		}
@@ -136,7 +84,12 @@ func main() {
 		partitionBatchers[partition] = bat
 	}
 
-	part := partitioner.NewPartitioner[event.PageViewEvent](func(ctx context.Context, partition int, message event.PageViewEvent, callback publisher.Callback[event.PageViewEvent]) error {
+	sequencer, err := partitioner.NewSequencer(kafkaPartitionCount)
+	if err != nil {
+		zap.L().Fatal(err.Error())
+	}
+
+	part := partitioner.NewPartitioner[event.PageViewEvent](partitioner.WithSequence(sequencer, func(ctx context.Context, partition int, message event.PageViewEvent, callback publisher.Callback[event.PageViewEvent]) error {
 		err := partitionBatchers[partition].Push(ctx, message, callback)
 		if err != nil {
 			zap.L().Error(err.Error())
@@ -144,12 +97,14 @@ func main() {
 		}
 
 		return nil
-	})
-	if err := part.SetRoundRobinMode(kafkaPartitionCount); err != nil {
+	}))
+	if err := part.SetKeyMode(func(m event.PageViewEvent) string {
+		return m.PartitionKey()
+	}, kafkaPartitionCount); err != nil {
 		zap.L().Fatal(err.Error())
 	}
 
-	pub := publisher.NewPublisher[event.PageViewEvent](
+	pub, err := publisher.NewPublisher[event.PageViewEvent](
 		ctx,
 		func(ctx context.Context, message event.PageViewEvent, callback publisher.Callback[event.PageViewEvent]) error {
 			if err := part.WriteFn(ctx, message, callback); err != nil {
@@ -161,21 +116,45 @@ func main() {
 		publisherWorkerCount,
 		publisherBufferAsyncMessageSize,
 	)
-	defer func() {
-		if err := pub.Close(); err != nil {
-			zap.L().Error(err.Error())
-		}
-	}()
+	if err != nil {
+		zap.L().Fatal(err.Error())
+	}
+
+	flushers := make([]pipeline.Flusher, len(partitionBatchers))
+	for i, bat := range partitionBatchers {
+		flushers[i] = bat
+	}
 
-	for ev := range gen.Events() {
-		if err := pub.SendAsync(ctx, ev.Event, func(ctx context.Context, message event.PageViewEvent, err error) {
+	pipe := pipeline.NewPipeline(gen, pub,
+		pipeline.WithFlushers(flushers...),
+		pipeline.WithClosers(pool),
+		pipeline.WithCallback(func(ctx context.Context, message event.PageViewEvent, err error) {
 			zap.L().Info(
 				"event sent",
 				zap.String("user_id", message.UserID),
 				zap.Bool("success", err == nil),
 			)
-		}); err != nil {
+		}),
+	)
+
+	signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-signalCtx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+		defer cancel()
+
+		if err := pipe.Shutdown(shutdownCtx); err != nil {
 			zap.L().Error(err.Error())
 		}
+	}()
+
+	if err := pipe.Run(ctx); err != nil {
+		zap.L().Error(err.Error())
 	}
+	<-shutdownDone
 }
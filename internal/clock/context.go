@@ -0,0 +1,30 @@
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// WithTimeout аналог context.WithTimeout для часов clk.
+// Для реальных часов используется context.WithTimeout, для остальных
+// контекст отменяется по таймеру clk с причиной context.DeadlineExceeded
+// (см. context.Cause).
+func WithTimeout(ctx context.Context, clk Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clk.(realClock); ok {
+		return context.WithTimeout(ctx, d)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := clk.NewTimer(d)
+
+	go func() {
+		select {
+		case <-timer.C():
+			cancel(context.DeadlineExceeded)
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}()
+
+	return ctx, func() { cancel(context.Canceled) }
+}
@@ -0,0 +1,186 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake управляемые часы для детерминированных тестов.
+// Время меняется только вызовом Advance, таймеры и тикеры
+// срабатывают при достижении своего срока.
+type Fake struct {
+	m       sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter общий механизм для фейковых таймеров и тикеров.
+// Для таймера period равен нулю.
+type fakeWaiter struct {
+	clock    *Fake
+	ch       chan time.Time
+	deadline time.Time
+	period   time.Duration
+	active   bool
+}
+
+// NewFake создает фейковые часы, начинающие отсчет с now.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.m)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	return &fakeTicker{f.add(d, d)}
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return &fakeTimer{f.add(d, 0)}
+}
+
+// Advance сдвигает время на d и срабатывает все просроченные таймеры и тикеры.
+// Как и time.Ticker, тикер не накапливает пропущенные срабатывания.
+func (f *Fake) Advance(d time.Duration) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.now = f.now.Add(d)
+
+	for _, w := range f.waiters {
+		if !w.active || w.deadline.After(f.now) {
+			continue
+		}
+
+		select {
+		case w.ch <- f.now:
+		default:
+		}
+
+		if w.period == 0 {
+			w.active = false
+			continue
+		}
+
+		for !w.deadline.After(f.now) {
+			w.deadline = w.deadline.Add(w.period)
+		}
+	}
+
+	f.compact()
+	f.cond.Broadcast()
+}
+
+// BlockUntil блокируется, пока количество активных таймеров и тикеров
+// не станет не меньше n. Позволяет дождаться, что тестируемый код
+// создал свои таймеры, перед вызовом Advance.
+func (f *Fake) BlockUntil(n int) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// add регистрирует новый таймер или тикер.
+func (f *Fake) add(d, period time.Duration) *fakeWaiter {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	w := &fakeWaiter{
+		clock:    f,
+		ch:       make(chan time.Time, 1),
+		deadline: f.now.Add(d),
+		period:   period,
+		active:   true,
+	}
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+
+	return w
+}
+
+// compact удаляет из списка неактивные таймеры.
+func (f *Fake) compact() {
+	active := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.active {
+			active = append(active, w)
+		}
+	}
+	clear(f.waiters[len(active):])
+	f.waiters = active
+}
+
+// stop деактивирует таймер, возвращает true, если он был активен.
+func (w *fakeWaiter) stop() bool {
+	w.clock.m.Lock()
+	defer w.clock.m.Unlock()
+
+	wasActive := w.active
+	w.active = false
+	w.clock.compact()
+	w.clock.cond.Broadcast()
+
+	return wasActive
+}
+
+// reset перезапускает таймер со сроком d от текущего времени.
+func (w *fakeWaiter) reset(d time.Duration) bool {
+	w.clock.m.Lock()
+	defer w.clock.m.Unlock()
+
+	wasActive := w.active
+	w.deadline = w.clock.now.Add(d)
+	if w.period != 0 {
+		w.period = d
+	}
+	if !wasActive {
+		w.active = true
+		w.clock.waiters = append(w.clock.waiters, w)
+	}
+	w.clock.cond.Broadcast()
+
+	return wasActive
+}
+
+type fakeTicker struct {
+	w *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.w.stop()
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	t.w.reset(d)
+}
+
+type fakeTimer struct {
+	w *fakeWaiter
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	return t.w.stop()
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	return t.w.reset(d)
+}
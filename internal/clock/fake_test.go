@@ -0,0 +1,102 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFake_TimerFiresOnAdvance(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewFake(start)
+
+	timer := c.NewTimer(time.Second)
+
+	c.Advance(500 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before deadline")
+	default:
+	}
+
+	c.Advance(500 * time.Millisecond)
+	select {
+	case now := <-timer.C():
+		if !now.Equal(start.Add(time.Second)) {
+			t.Fatalf("unexpected fire time %v", now)
+		}
+	default:
+		t.Fatal("timer did not fire after deadline")
+	}
+
+	if timer.Stop() {
+		t.Fatal("fired timer must not be active")
+	}
+}
+
+func TestFake_TickerRepeats(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+
+	ticker := c.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for i := range 3 {
+		c.Advance(time.Second)
+		select {
+		case <-ticker.C():
+		default:
+			t.Fatalf("ticker did not fire on tick %d", i)
+		}
+	}
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+
+	done := make(chan struct{})
+	go func() {
+		c.BlockUntil(1)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("BlockUntil returned without timers")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	c.NewTimer(time.Second)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("BlockUntil did not return after timer creation")
+	}
+}
+
+func TestWithTimeout_Fake(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+
+	ctx, cancel := WithTimeout(context.Background(), c, time.Second)
+	defer cancel()
+
+	c.BlockUntil(1)
+	c.Advance(999 * time.Millisecond)
+	select {
+	case <-ctx.Done():
+		t.Fatal("context canceled before timeout")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.Advance(time.Millisecond)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context was not canceled after timeout")
+	}
+
+	if !errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded cause, got %v", context.Cause(ctx))
+	}
+}
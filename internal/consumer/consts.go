@@ -9,4 +9,13 @@ const (
 	maxBatchSize      = 10_000
 	defaultBatchSize  = minBatchSize
 	defaultPeriodTime = 5 * time.Second
+	defaultByteLimit  = 1 << 20
+	// defaultMaxBatchAge максимальное время удержания буфера при объединении батчей
+	defaultMaxBatchAge = time.Minute
+	// validationAttempts количество попыток валидации сообщения перед первой отправкой в DLQ
+	validationAttempts = 1
+	// flushQueueSize размер очереди батчей, ожидающих свободного flush-воркера
+	flushQueueSize = 64
+	// outBufferSize размер буфера канала Out по умолчанию
+	outBufferSize = 64
 )
@@ -1,6 +1,7 @@
 package consumer
 
 import (
+	"ay-events-generator/internal/clock"
 	"context"
 	"slices"
 	"sync"
@@ -18,28 +19,64 @@ type Consumer[T any] struct {
 	mode           Mode
 	buffer         []T
 	batchSize      atomic.Int32
+	byteLimit      atomic.Int64
+	maxBufferLen   atomic.Int64
+	minBatchSize   atomic.Int32
+	maxBatchAge    atomic.Int64
+	bufferSince    time.Time
+	bufferBytes    int
+	sizeFn         SizeFn[T]
 	flushFn        FlushFn[T]
 	tickerPeriod   atomic.Value
 	dlq            chan DLQMessage[T]
+	hasDLQHandler  atomic.Bool
 	closeCh        chan struct{}
 	closedWg       sync.WaitGroup
+	stopCh         chan struct{}
+	processWg      sync.WaitGroup
 	closed         atomic.Bool
+	clock          clock.Clock
+	logger         *zap.Logger
+
+	flushSuccessListeners []FlushSuccessListener
+
+	flushMu       sync.RWMutex
+	flushPool     *flushPool[T]
+	flushOverflow FlushOverflowPolicy
+
+	outMu       sync.Mutex
+	out         chan []T
+	outOverflow OutOverflowPolicy
+	outDropped  atomic.Uint64
+
+	dedup      atomic.Pointer[dedupWindow[T]]
+	duplicates atomic.Uint64
 }
 
 // NewConsumer создает новый Consumer и сразу запускает обработку сообщений
 // в соответствии с текущим режимом работы.
-func NewConsumer[T any](ctx context.Context, validMessageFn ValidMessageFn[T], flushFn FlushFn[T]) *Consumer[T] {
+// flushFn может быть nil, если батчи читаются из Out.
+func NewConsumer[T any](ctx context.Context, validMessageFn ValidMessageFn[T], flushFn FlushFn[T], opts ...Option[T]) *Consumer[T] {
 	c := &Consumer[T]{
 		validMessageFn: validMessageFn,
 		readCh:         make(chan T),
 		buffer:         make([]T, 0, bufferSize),
 		flushFn:        flushFn,
 		dlq:            make(chan DLQMessage[T], dlqBufferSize),
+		clock:          clock.Real(),
+		logger:         zap.L(),
+		flushOverflow:  FlushOverflowBlock,
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
 
 	c.closed.Store(true)
 	c.batchSize.Store(defaultBatchSize)
 	c.tickerPeriod.Store(defaultPeriodTime)
+	c.byteLimit.Store(defaultByteLimit)
+	c.maxBatchAge.Store(int64(defaultMaxBatchAge))
 
 	c.start(ctx)
 
@@ -47,15 +84,23 @@ func NewConsumer[T any](ctx context.Context, validMessageFn ValidMessageFn[T], f
 }
 
 // SetMode изменяет режим работы Consumer (Batch / Time / Hybrid).
-// Перед переключением останавливает текущие горутины.
+// Перезапускает только обработчик: накопленный буфер переходит к обработчику
+// нового режима, а каналы, полученные через In, продолжают работать.
+// Закрытый Consumer запускается заново.
 func (c *Consumer[T]) SetMode(ctx context.Context, mode Mode) error {
-	if err := c.Close(); err != nil {
-		zap.L().Error(err.Error())
-		return err
+	if mode == SizeBytesMode && c.sizeFn == nil {
+		return ErrSizeFnNotFound
 	}
 
+	if c.closed.Load() {
+		c.mode = mode
+		c.start(ctx)
+		return nil
+	}
+
+	c.stopProcessor()
 	c.mode = mode
-	c.start(ctx)
+	c.startProcessor(ctx)
 
 	return nil
 }
@@ -72,6 +117,57 @@ func (c *Consumer[T]) SetBatchSize(size int32) error {
 	return nil
 }
 
+// SetByteLimit задает предельный суммарный размер буфера в байтах
+// для режима SizeBytesMode.
+func (c *Consumer[T]) SetByteLimit(n int) error {
+	if n <= 0 {
+		return ErrInvalidByteLimit
+	}
+
+	c.byteLimit.Store(int64(n))
+
+	return nil
+}
+
+// SetMaxBufferLen ограничивает количество сообщений в буфере.
+// Сообщения сверх лимита не накапливаются, а попадают в DLQ с ErrBufferSaturated,
+// сигнализируя о том, что входной поток опережает flush.
+func (c *Consumer[T]) SetMaxBufferLen(n int) error {
+	if n <= 0 {
+		return ErrInvalidMaxBufferLen
+	}
+
+	c.maxBufferLen.Store(int64(n))
+
+	return nil
+}
+
+// SetMinBatchSize задает минимальный размер батча для flush по таймеру.
+// Пока в буфере меньше n сообщений, тик пропускается и буфер сохраняется,
+// но не дольше maxBatchAge с момента поступления первого сообщения.
+// Нулевое значение отключает объединение.
+func (c *Consumer[T]) SetMinBatchSize(n int) error {
+	if n < 0 || n > maxBatchSize {
+		return ErrInvalidBatchSize
+	}
+
+	c.minBatchSize.Store(int32(n))
+
+	return nil
+}
+
+// SetMaxBatchAge задает максимальное время удержания сообщений в буфере
+// при объединении батчей через SetMinBatchSize.
+func (c *Consumer[T]) SetMaxBatchAge(age time.Duration) error {
+	if age <= 0 {
+		return ErrInvalidMaxBatchAge
+	}
+
+	c.maxBatchAge.Store(int64(age))
+
+	return nil
+}
+
 // SetTickerPeriod задает период срабатывания таймера
 // для Time и Hybrid режимов.
 func (c *Consumer[T]) SetTickerPeriod(period time.Duration) {
@@ -99,19 +195,21 @@ func (c *Consumer[T]) In(ctx context.Context) chan<- T {
 			case v := <-in:
 				err = c.validMessageFn(v)
 				if err != nil {
-					select {
-					case c.dlq <- DLQMessage[T]{
-						Message: v,
-						Err:     err,
-					}:
-					default:
-						zap.L().Error("dlq is full, dropping message")
-					}
+					c.toDLQ(v, err, validationAttempts)
+					continue
+				}
 
+				if c.isDuplicate(v) {
 					continue
 				}
 
-				c.readCh <- v
+				select {
+				case <-c.closeCh:
+					return
+				case <-ctx.Done():
+					return
+				case c.readCh <- v:
+				}
 			}
 		}
 	}()
@@ -123,22 +221,122 @@ func (c *Consumer[T]) DLQ() <-chan DLQMessage[T] {
 	return c.dlq
 }
 
+// AddFlushSuccessListener добавляет слушателя, вызываемого после того,
+// как flushFn вернул nil. При ошибке flush слушатели не вызываются.
+func (c *Consumer[T]) AddFlushSuccessListener(fn FlushSuccessListener) {
+	c.flushSuccessListeners = append(c.flushSuccessListeners, fn)
+}
+
+func (c *Consumer[T]) callFlushSuccessListeners(count int) {
+	for _, fn := range c.flushSuccessListeners {
+		fn(count)
+	}
+}
+
+// OnDLQ запускает горутину, передающую сообщения из DLQ в handler,
+// до закрытия Consumer. Допускается только один обработчик,
+// повторная регистрация возвращает ErrDLQHandlerExists.
+func (c *Consumer[T]) OnDLQ(handler DLQHandler[T]) error {
+	if c.hasDLQHandler.Swap(true) {
+		return ErrDLQHandlerExists
+	}
+
+	closeCh := c.closeCh
+
+	c.closedWg.Add(1)
+	go func() {
+		defer c.closedWg.Done()
+
+		for {
+			select {
+			case <-closeCh:
+				return
+			case m := <-c.dlq:
+				handler(m)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Replay повторно валидирует сообщение из DLQ и передает его в обработку,
+// минуя дедупликацию. При повторной ошибке валидации сообщение
+// возвращается в DLQ с увеличенным Attempts, а ошибка возвращается вызывающему.
+// Предназначен для вызова из обработчика OnDLQ.
+func (c *Consumer[T]) Replay(ctx context.Context, m DLQMessage[T]) error {
+	if c.closed.Load() {
+		return ErrConsumerClosed
+	}
+
+	if err := c.validMessageFn(m.Message); err != nil {
+		c.toDLQ(m.Message, err, m.Attempts+1)
+		return err
+	}
+
+	select {
+	case <-c.closeCh:
+		return ErrConsumerClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	case c.readCh <- m.Message:
+		return nil
+	}
+}
+
+// toDLQ отправляет сообщение в DLQ, не блокируясь, отмечая время попадания
+// и количество сделанных попыток валидации.
+// Если DLQ переполнена, сообщение отбрасывается.
+func (c *Consumer[T]) toDLQ(v T, err error, attempts int) {
+	select {
+	case c.dlq <- DLQMessage[T]{
+		Message:   v,
+		Err:       err,
+		Timestamp: c.clock.Now(),
+		Attempts:  attempts,
+	}:
+	default:
+		c.logger.Error("dlq is full, dropping message")
+	}
+}
+
+// push добавляет сообщение в буфер.
+// Если буфер достиг maxBufferLen, сообщение отправляется в DLQ
+// с ошибкой ErrBufferSaturated и push возвращает false.
+func (c *Consumer[T]) push(v T) bool {
+	if limit := c.maxBufferLen.Load(); limit > 0 && len(c.buffer) >= int(limit) {
+		c.logger.Error(ErrBufferSaturated.Error())
+		c.toDLQ(v, ErrBufferSaturated, validationAttempts)
+		return false
+	}
+
+	if len(c.buffer) == 0 {
+		c.bufferSince = c.clock.Now()
+	}
+
+	c.buffer = append(c.buffer, v)
+	return true
+}
+
 // batchProcess накапливает сообщения и вызывает flush
 // только при достижении batchSize.
-func (c *Consumer[T]) batchProcess(ctx context.Context) {
-	c.closedWg.Add(1)
+func (c *Consumer[T]) batchProcess(ctx context.Context, stop <-chan struct{}) {
+	c.processWg.Add(1)
 
 	go func() {
-		defer c.closedWg.Done()
+		defer c.processWg.Done()
 
 		for {
 			select {
 			case <-ctx.Done():
+				c.flushOnDone(ctx)
 				return
-			case <-c.closeCh:
+			case <-stop:
 				return
 			case v := <-c.readCh:
-				c.buffer = append(c.buffer, v)
+				if !c.push(v) {
+					continue
+				}
 
 				if int(c.batchSize.Load()) <= len(c.buffer) {
 					c.flush(ctx)
@@ -150,25 +348,26 @@ func (c *Consumer[T]) batchProcess(ctx context.Context) {
 
 // timeProcess накапливает сообщения и вызывает flush
 // по таймеру, независимо от размера буфера.
-func (c *Consumer[T]) timeProcess(ctx context.Context) {
-	c.closedWg.Add(1)
+func (c *Consumer[T]) timeProcess(ctx context.Context, stop <-chan struct{}) {
+	c.processWg.Add(1)
 
 	go func() {
-		defer c.closedWg.Done()
+		defer c.processWg.Done()
 
-		ticker := time.NewTicker(c.tickerPeriod.Load().(time.Duration))
+		ticker := c.clock.NewTicker(c.tickerPeriod.Load().(time.Duration))
 		defer ticker.Stop()
 
 		for {
 			select {
-			case <-c.closeCh:
+			case <-stop:
 				return
 			case <-ctx.Done():
+				c.flushOnDone(ctx)
 				return
-			case <-ticker.C:
-				c.flush(ctx)
+			case <-ticker.C():
+				c.tickFlush(ctx)
 			case v := <-c.readCh:
-				c.buffer = append(c.buffer, v)
+				c.push(v)
 			}
 		}
 	}()
@@ -176,25 +375,28 @@ func (c *Consumer[T]) timeProcess(ctx context.Context) {
 
 // hybridProcess комбинирует batch и time подходы.
 // Flush вызывается либо по таймеру, либо при достижении batchSize.
-func (c *Consumer[T]) hybridProcess(ctx context.Context) {
-	c.closedWg.Add(1)
+func (c *Consumer[T]) hybridProcess(ctx context.Context, stop <-chan struct{}) {
+	c.processWg.Add(1)
 
 	go func() {
-		defer c.closedWg.Done()
+		defer c.processWg.Done()
 
-		ticker := time.NewTicker(c.tickerPeriod.Load().(time.Duration))
+		ticker := c.clock.NewTicker(c.tickerPeriod.Load().(time.Duration))
 		defer ticker.Stop()
 
 		for {
 			select {
-			case <-c.closeCh:
+			case <-stop:
 				return
 			case <-ctx.Done():
+				c.flushOnDone(ctx)
 				return
-			case <-ticker.C:
-				c.flush(ctx)
+			case <-ticker.C():
+				c.tickFlush(ctx)
 			case v := <-c.readCh:
-				c.buffer = append(c.buffer, v)
+				if !c.push(v) {
+					continue
+				}
 				if int(c.batchSize.Load()) <= len(c.buffer) {
 					ticker.Reset(c.tickerPeriod.Load().(time.Duration))
 					c.flush(ctx)
@@ -204,6 +406,66 @@ func (c *Consumer[T]) hybridProcess(ctx context.Context) {
 	}()
 }
 
+// sizeBytesProcess накапливает сообщения и вызывает flush, когда суммарный
+// размер буфера достигает byteLimit. Если очередное сообщение не помещается
+// в лимит, накопленный буфер сбрасывается до его добавления.
+func (c *Consumer[T]) sizeBytesProcess(ctx context.Context, stop <-chan struct{}) {
+	// буфер мог перейти из другого режима, пересчитываем его размер
+	c.bufferBytes = 0
+	for _, v := range c.buffer {
+		c.bufferBytes += c.sizeFn(v)
+	}
+
+	c.processWg.Add(1)
+
+	go func() {
+		defer c.processWg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				c.flushOnDone(ctx)
+				return
+			case <-stop:
+				return
+			case v := <-c.readCh:
+				size := c.sizeFn(v)
+				limit := int(c.byteLimit.Load())
+
+				if len(c.buffer) > 0 && c.bufferBytes+size > limit {
+					c.flush(ctx)
+				}
+
+				if !c.push(v) {
+					continue
+				}
+				c.bufferBytes += size
+
+				if c.bufferBytes >= limit {
+					c.flush(ctx)
+				}
+			}
+		}
+	}()
+}
+
+// tickFlush вызывает flush по таймеру, пропуская тик, если в буфере
+// меньше minBatchSize сообщений и они ожидают меньше maxBatchAge.
+func (c *Consumer[T]) tickFlush(ctx context.Context) {
+	minSize := int(c.minBatchSize.Load())
+	if len(c.buffer) < minSize && c.clock.Now().Sub(c.bufferSince) < time.Duration(c.maxBatchAge.Load()) {
+		return
+	}
+
+	c.flush(ctx)
+}
+
+// flushOnDone сбрасывает остаток буфера при отмене контекста обработчика.
+// flushFn получает контекст без отмены, сохраняющий значения исходного.
+func (c *Consumer[T]) flushOnDone(ctx context.Context) {
+	c.flush(context.WithoutCancel(ctx))
+}
+
 // flush отправляет накопленные сообщения в flushFn.
 // Буфер копируется, очищается и передается в flush асинхронно.
 func (c *Consumer[T]) flush(ctx context.Context) {
@@ -213,12 +475,9 @@ func (c *Consumer[T]) flush(ctx context.Context) {
 
 	buf := slices.Clone(c.buffer[:])
 	c.buffer = c.buffer[:0]
+	c.bufferBytes = 0
 
-	go func(ctx context.Context) {
-		if err := c.flushFn(ctx, buf); err != nil {
-			zap.L().Error(err.Error())
-		}
-	}(ctx)
+	c.dispatchFlush(ctx, buf)
 }
 
 // start запускает обработку сообщений
@@ -229,25 +488,43 @@ func (c *Consumer[T]) start(ctx context.Context) {
 	}
 
 	c.closeCh = make(chan struct{})
+	c.startProcessor(ctx)
+}
+
+// startProcessor запускает обработчик текущего режима.
+func (c *Consumer[T]) startProcessor(ctx context.Context) {
+	c.stopCh = make(chan struct{})
 
 	switch c.mode {
 	case BatchMode:
-		c.batchProcess(ctx)
+		c.batchProcess(ctx, c.stopCh)
 	case TimeMode:
-		c.timeProcess(ctx)
+		c.timeProcess(ctx, c.stopCh)
 	case HybridMode:
-		c.hybridProcess(ctx)
+		c.hybridProcess(ctx, c.stopCh)
+	case SizeBytesMode:
+		c.sizeBytesProcess(ctx, c.stopCh)
 	}
 }
 
+// stopProcessor останавливает обработчик и дожидается его завершения.
+// Буфер при этом сохраняется.
+func (c *Consumer[T]) stopProcessor() {
+	close(c.stopCh)
+	c.processWg.Wait()
+}
+
 // Close сигнализирует всем внутренним горутинам о завершении
-// и дожидается их корректной остановки.
+// и дожидается их корректной остановки, включая воркеры SetFlushWorkers.
+// После повторного запуска через SetMode пул нужно задать заново.
 func (c *Consumer[T]) Close() error {
 	if c.closed.Swap(true) {
 		return nil
 	}
 
 	close(c.closeCh)
+	c.stopProcessor()
+	c.replaceFlushPool(nil)
 	c.closedWg.Wait()
 	return nil
 }
@@ -1,8 +1,13 @@
 package consumer
 
 import (
+	"ay-events-generator/internal/clock"
+	"ay-events-generator/internal/event"
 	"context"
 	"errors"
+	"runtime"
+	"slices"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -197,3 +202,850 @@ func TestInvalidMessagesGoToDLQ(t *testing.T) {
 
 	_ = c.Close()
 }
+
+// TestDLQMessageMetadata проверяет, что сообщение в DLQ получает
+// время попадания из часов Consumer и количество попыток валидации
+func TestDLQMessageMetadata(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Unix(1_700_000_000, 0)
+	c := NewConsumer[string](ctx, func(data string) error {
+		return errors.New("invalid message")
+	}, func(ctx context.Context, buf []string) error {
+		return nil
+	}, WithClock[string](clock.NewFake(now)))
+
+	_ = c.SetMode(ctx, BatchMode)
+
+	c.In(ctx) <- "bad-message"
+
+	select {
+	case msg := <-c.DLQ():
+		if !msg.Timestamp.Equal(now) {
+			t.Fatalf("expected timestamp %v, got %v", now, msg.Timestamp)
+		}
+		if msg.Attempts != validationAttempts {
+			t.Fatalf("expected %d attempt, got %d", validationAttempts, msg.Attempts)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("DLQ did not receive message")
+	}
+
+	_ = c.Close()
+}
+
+// TestReplayIncrementsAttempts проверяет, что каждый неудачный повтор
+// через Replay возвращает сообщение в DLQ с увеличенным Attempts,
+// а успешный повтор передает сообщение во flush
+func TestReplayIncrementsAttempts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var valid atomic.Bool
+	flushed := make(chan []string, 1)
+	c := NewConsumer[string](ctx, func(data string) error {
+		if !valid.Load() {
+			return errors.New("invalid message")
+		}
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		flushed <- slices.Clone(buf)
+		return nil
+	})
+
+	_ = c.SetMode(ctx, BatchMode)
+
+	replays := make(chan DLQMessage[string], 1)
+	if err := c.OnDLQ(func(m DLQMessage[string]) {
+		replays <- m
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.In(ctx) <- "message"
+
+	for attempts := validationAttempts; attempts <= 3; attempts++ {
+		select {
+		case m := <-replays:
+			if m.Attempts != attempts {
+				t.Fatalf("expected %d attempts, got %d", attempts, m.Attempts)
+			}
+			if attempts == 3 {
+				valid.Store(true)
+				if err := c.Replay(ctx, m); err != nil {
+					t.Fatal(err)
+				}
+				break
+			}
+			if err := c.Replay(ctx, m); err == nil {
+				t.Fatal("expected validation error on replay")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("DLQ handler did not receive message")
+		}
+	}
+
+	select {
+	case buf := <-flushed:
+		if len(buf) != 1 || buf[0] != "message" {
+			t.Fatalf("unexpected flush %v", buf)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("replayed message was not flushed")
+	}
+
+	_ = c.Close()
+
+	if err := c.Replay(ctx, DLQMessage[string]{Message: "late"}); !errors.Is(err, ErrConsumerClosed) {
+		t.Fatalf("expected ErrConsumerClosed, got %v", err)
+	}
+}
+
+// TestInExitsOnCloseWithPendingSend проверяет, что проксирующая горутина In
+// не блокируется на отправке в остановленный обработчик и завершается при Close
+func TestInExitsOnCloseWithPendingSend(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	before := runtime.NumGoroutine()
+
+	c := NewConsumer[string](ctx, func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		return nil
+	})
+
+	// режим не задан, обработчик не читает readCh — отправка зависает
+	in := c.In(ctx)
+	in <- "a"
+
+	done := make(chan struct{})
+	go func() {
+		_ = c.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close() deadlocked on pending send")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("goroutine leak: %d before, %d after", before, after)
+	}
+}
+
+// TestTimeModeFlushFakeClock проверяет flush по таймеру с управляемыми часами
+func TestTimeModeFlushFakeClock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clk := clock.NewFake(time.Unix(0, 0))
+	flushed := make(chan []string, 1)
+
+	c := NewConsumer[string](ctx, func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		flushed <- buf
+		return nil
+	}, WithClock[string](clk))
+	c.SetTickerPeriod(time.Hour)
+	_ = c.SetMode(ctx, TimeMode)
+
+	in := c.In(ctx)
+	// второе сообщение принимается только после того, как первое попало в обработчик
+	in <- "a"
+	in <- "b"
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Hour)
+
+	select {
+	case buf := <-flushed:
+		if len(buf) == 0 || buf[0] != "a" {
+			t.Fatalf("expected flushed batch to start with 'a', got %v", buf)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("flush was not triggered by the fake clock")
+	}
+
+	_ = c.Close()
+}
+
+// TestSizeBytesModeFlush проверяет flush по суммарному размеру сообщений
+func TestSizeBytesModeFlush(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flushed := make(chan []string, 10)
+
+	c := NewConsumer[string](ctx, func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		flushed <- buf
+		return nil
+	}, WithSizeFn[string](func(data string) int {
+		return len(data)
+	}))
+
+	if err := c.SetByteLimit(10); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetMode(ctx, SizeBytesMode); err != nil {
+		t.Fatal(err)
+	}
+
+	in := c.In(ctx)
+	// 4+4 байта помещаются, 3 байта превышают лимит — первый flush до их добавления;
+	// 3+7 байт ровно достигают лимита — второй flush
+	for _, m := range []string{"aaaa", "bbbb", "ccc", "ddddddd"} {
+		in <- m
+	}
+
+	// flushFn вызывается асинхронно, поэтому порядок батчей не гарантирован
+	expected := map[string]bool{"aaaa,bbbb": true, "ccc,ddddddd": true}
+	for i := range len(expected) {
+		select {
+		case got := <-flushed:
+			key := strings.Join(got, ",")
+			if !expected[key] {
+				t.Fatalf("flush %d: unexpected batch %v", i, got)
+			}
+			delete(expected, key)
+		case <-time.After(time.Second):
+			t.Fatalf("flush %d timed out", i)
+		}
+	}
+
+	_ = c.Close()
+}
+
+// TestSizeBytesModeRequiresSizeFn проверяет, что режим недоступен без функции размера
+func TestSizeBytesModeRequiresSizeFn(t *testing.T) {
+	c := NewConsumer[string](context.Background(), func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		return nil
+	})
+
+	if err := c.SetMode(context.Background(), SizeBytesMode); !errors.Is(err, ErrSizeFnNotFound) {
+		t.Fatalf("expected ErrSizeFnNotFound, got %v", err)
+	}
+	if err := c.SetByteLimit(0); !errors.Is(err, ErrInvalidByteLimit) {
+		t.Fatalf("expected ErrInvalidByteLimit, got %v", err)
+	}
+
+	_ = c.Close()
+}
+
+// TestBufferSaturation проверяет, что при переполнении буфера сообщения
+// попадают в DLQ с ErrBufferSaturated вместо неограниченного роста буфера
+func TestBufferSaturation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewConsumer[string](ctx, func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		return nil
+	})
+	c.SetTickerPeriod(time.Hour)
+	if err := c.SetMaxBufferLen(3); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.SetMode(ctx, TimeMode)
+
+	in := c.In(ctx)
+	for _, m := range []string{"a", "b", "c", "d", "e"} {
+		in <- m
+	}
+
+	for _, expected := range []string{"d", "e"} {
+		select {
+		case msg := <-c.DLQ():
+			if msg.Message != expected || !errors.Is(msg.Err, ErrBufferSaturated) {
+				t.Fatalf("expected %q with ErrBufferSaturated, got %q with %v", expected, msg.Message, msg.Err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("saturation was not signaled")
+		}
+	}
+
+	_ = c.Close()
+
+	if len(c.buffer) != 3 {
+		t.Fatalf("expected buffer to be capped at 3, got %d", len(c.buffer))
+	}
+	if err := c.SetMaxBufferLen(0); !errors.Is(err, ErrInvalidMaxBufferLen) {
+		t.Fatalf("expected ErrInvalidMaxBufferLen, got %v", err)
+	}
+}
+
+// TestMinBatchSizeCoalescesTicks проверяет, что при слабой нагрузке тики
+// не сбрасывают маленькие батчи, пока не набран минимум или не истек maxBatchAge
+func TestMinBatchSizeCoalescesTicks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clk := clock.NewFake(time.Unix(0, 0))
+	flushed := make(chan []string, 1)
+
+	c := NewConsumer[string](ctx, func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		flushed <- buf
+		return nil
+	}, WithClock[string](clk))
+	if err := c.SetMinBatchSize(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetMaxBatchAge(3 * time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// обработчик остановлен, сообщения и тики подаются напрямую из теста,
+	// поэтому порядок событий не зависит от планировщика
+	_ = c.Close()
+
+	push := func(values ...string) {
+		for _, v := range values {
+			c.push(v)
+		}
+	}
+	tick := func() {
+		clk.Advance(time.Hour)
+		c.tickFlush(ctx)
+	}
+	expectBuffered := func(n int) {
+		t.Helper()
+		if len(c.buffer) != n {
+			t.Fatalf("expected %d buffered messages, got %v", n, c.buffer)
+		}
+	}
+	expectFlush := func() []string {
+		t.Helper()
+		select {
+		case buf := <-flushed:
+			return buf
+		case <-time.After(time.Second):
+			t.Fatal("flush was not triggered")
+			return nil
+		}
+	}
+
+	push("a", "b")
+	tick()
+	expectBuffered(2)
+
+	push("c", "d", "e")
+	tick()
+	expectBuffered(0)
+	if buf := expectFlush(); len(buf) != 5 {
+		t.Fatalf("expected coalesced batch of 5, got %v", buf)
+	}
+
+	push("f", "g")
+	tick()
+	tick()
+	expectBuffered(2)
+
+	// сообщения ожидают maxBatchAge, буфер сбрасывается несмотря на малый размер
+	tick()
+	expectBuffered(0)
+	if buf := expectFlush(); len(buf) != 2 {
+		t.Fatalf("expected small batch flushed by max age, got %v", buf)
+	}
+}
+
+// TestSetModeKeepsBuffer проверяет, что при смене режима накопленные сообщения
+// переходят к новому обработчику, а канал In продолжает работать
+func TestSetModeKeepsBuffer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flushed := make(chan []string, 1)
+
+	c := NewConsumer[string](ctx, func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		flushed <- buf
+		return nil
+	})
+	_ = c.SetBatchSize(5)
+	c.SetTickerPeriod(time.Hour)
+	_ = c.SetMode(ctx, BatchMode)
+
+	in := c.In(ctx)
+	in <- "a"
+	in <- "b"
+	in <- "c"
+
+	if err := c.SetMode(ctx, HybridMode); err != nil {
+		t.Fatal(err)
+	}
+
+	in <- "d"
+	in <- "e"
+
+	select {
+	case buf := <-flushed:
+		expected := []string{"a", "b", "c", "d", "e"}
+		if !slices.Equal(buf, expected) {
+			t.Fatalf("expected %v, got %v", expected, buf)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("flush was not triggered after mode switch")
+	}
+
+	_ = c.Close()
+}
+
+// TestFlushOnContextDone проверяет, что при отмене контекста обработчик
+// сбрасывает накопленный буфер, а не теряет его
+func TestFlushOnContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	flushed := make(chan []string, 1)
+
+	c := NewConsumer[string](ctx, func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		if ctx.Err() != nil {
+			t.Errorf("expected flush context not to be canceled, got %v", ctx.Err())
+		}
+		flushed <- buf
+		return nil
+	})
+	_ = c.SetBatchSize(10)
+	_ = c.SetMode(ctx, BatchMode)
+
+	in := c.In(context.Background())
+	in <- "a"
+	in <- "b"
+	// дожидаемся, пока обработчик примет второе сообщение
+	in <- "c"
+
+	cancel()
+
+	select {
+	case buf := <-flushed:
+		if len(buf) < 2 || buf[0] != "a" || buf[1] != "b" {
+			t.Fatalf("expected buffered messages to be flushed, got %v", buf)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("buffer was not flushed on context cancellation")
+	}
+
+	_ = c.Close()
+}
+
+// TestOnDLQ проверяет, что зарегистрированный обработчик получает сообщения DLQ,
+// а повторная регистрация отклоняется
+func TestOnDLQ(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewConsumer[string](ctx, func(data string) error {
+		return errors.New("invalid message")
+	}, func(ctx context.Context, buf []string) error {
+		return nil
+	})
+
+	received := make(chan DLQMessage[string], 1)
+	if err := c.OnDLQ(func(m DLQMessage[string]) {
+		received <- m
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.OnDLQ(func(m DLQMessage[string]) {}); !errors.Is(err, ErrDLQHandlerExists) {
+		t.Fatalf("expected ErrDLQHandlerExists, got %v", err)
+	}
+
+	c.In(ctx) <- "bad-message"
+
+	select {
+	case m := <-received:
+		if m.Message != "bad-message" || m.Err == nil {
+			t.Fatalf("unexpected DLQ message %+v", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("DLQ handler did not receive message")
+	}
+
+	_ = c.Close()
+}
+
+// TestFlushSuccessListener проверяет, что слушатель получает размер
+// успешно записанного батча и не вызывается при ошибке flushFn
+func TestFlushSuccessListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var fail atomic.Bool
+	flushed := make(chan struct{}, 2)
+
+	c := NewConsumer[string](ctx, func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		defer func() { flushed <- struct{}{} }()
+		if fail.Load() {
+			return errors.New("flush failed")
+		}
+		return nil
+	})
+
+	counts := make(chan int, 2)
+	c.AddFlushSuccessListener(func(count int) {
+		counts <- count
+	})
+
+	_ = c.SetBatchSize(3)
+	_ = c.SetMode(ctx, BatchMode)
+
+	in := c.In(ctx)
+	for _, m := range []string{"a", "b", "c"} {
+		in <- m
+	}
+
+	select {
+	case count := <-counts:
+		if count != 3 {
+			t.Fatalf("expected batch size 3, got %d", count)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("flush success listener was not called")
+	}
+	<-flushed
+
+	fail.Store(true)
+	for _, m := range []string{"d", "e", "f"} {
+		in <- m
+	}
+
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatal("flush timed out")
+	}
+
+	select {
+	case count := <-counts:
+		t.Fatalf("listener must not be called on flush error, got %d", count)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_ = c.Close()
+}
+
+// TestFlushWorkersLimitConcurrency проверяет, что одновременно выполняется
+// не более n flush, а батчи из очереди в итоге записываются
+func TestFlushWorkersLimitConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const (
+		workers = 2
+		batches = 10
+	)
+
+	var active, maxActive, done atomic.Int32
+	release := make(chan struct{})
+
+	c := NewConsumer[string](ctx, func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		n := active.Add(1)
+		for {
+			m := maxActive.Load()
+			if n <= m || maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-release
+		active.Add(-1)
+		done.Add(1)
+		return nil
+	})
+
+	if err := c.SetFlushWorkers(-1); !errors.Is(err, ErrInvalidFlushWorkers) {
+		t.Fatalf("expected ErrInvalidFlushWorkers, got %v", err)
+	}
+	if err := c.SetFlushWorkers(workers); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = c.SetBatchSize(1)
+	_ = c.SetMode(ctx, BatchMode)
+
+	in := c.In(ctx)
+	for range batches {
+		in <- "m"
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for active.Load() < workers && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := active.Load(); got != workers {
+		t.Fatalf("expected %d active flushes, got %d", workers, got)
+	}
+
+	close(release)
+
+	deadline = time.Now().Add(time.Second)
+	for done.Load() < batches && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := done.Load(); got != batches {
+		t.Fatalf("expected %d flushes, got %d", batches, got)
+	}
+	if got := maxActive.Load(); got > workers {
+		t.Fatalf("expected at most %d concurrent flushes, got %d", workers, got)
+	}
+
+	_ = c.Close()
+	if c.flushPool != nil {
+		t.Fatal("expected Close to stop flush pool")
+	}
+}
+
+// TestFlushOverflowDLQ проверяет отправку батча в DLQ при переполнении очереди
+func TestFlushOverflowDLQ(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	c := NewConsumer[int](ctx, func(data int) error {
+		return nil
+	}, func(ctx context.Context, buf []int) error {
+		<-release
+		return nil
+	})
+
+	if err := c.SetFlushOverflowPolicy("drop"); !errors.Is(err, ErrInvalidFlushOverflowPolicy) {
+		t.Fatalf("expected ErrInvalidFlushOverflowPolicy, got %v", err)
+	}
+	_ = c.SetFlushOverflowPolicy(FlushOverflowDLQ)
+	_ = c.SetFlushWorkers(1)
+	_ = c.SetBatchSize(1)
+	_ = c.SetMode(ctx, BatchMode)
+
+	received := make(chan DLQMessage[int], 1)
+	_ = c.OnDLQ(func(m DLQMessage[int]) {
+		received <- m
+	})
+
+	in := c.In(ctx)
+	for i := range flushQueueSize + 2 {
+		in <- i
+	}
+
+	select {
+	case m := <-received:
+		if !errors.Is(m.Err, ErrFlushQueueFull) {
+			t.Fatalf("expected ErrFlushQueueFull, got %v", m.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("overflowed batch was not sent to DLQ")
+	}
+
+	close(release)
+	_ = c.Close()
+}
+
+// TestOut проверяет, что успешно записанные батчи попадают в Out,
+// а при переполнении канала отбрасываются по политике, не блокируя flush
+// и не попадая в DLQ
+func TestOut(t *testing.T) {
+	cases := []struct {
+		policy OutOverflowPolicy
+		kept   []string
+	}{
+		{OutDropNewest, []string{"c", "d"}},
+		{OutDropOldest, []string{"e", "f"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			c := NewConsumer[string](ctx, func(data string) error {
+				return nil
+			}, nil)
+
+			if err := c.SetOutBuffer(0, tc.policy); !errors.Is(err, ErrInvalidOutBuffer) {
+				t.Fatalf("expected ErrInvalidOutBuffer, got %v", err)
+			}
+			if err := c.SetOutBuffer(1, "block"); !errors.Is(err, ErrInvalidOutOverflowPolicy) {
+				t.Fatalf("expected ErrInvalidOutOverflowPolicy, got %v", err)
+			}
+			if err := c.SetOutBuffer(1, tc.policy); err != nil {
+				t.Fatal(err)
+			}
+
+			_ = c.SetBatchSize(2)
+			_ = c.SetMode(ctx, BatchMode)
+
+			out := c.Out()
+			in := c.In(ctx)
+			for _, m := range []string{"a", "b"} {
+				in <- m
+			}
+
+			select {
+			case batch := <-out:
+				if !slices.Equal(batch, []string{"a", "b"}) {
+					t.Fatalf("expected batch [a b], got %v", batch)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("batch did not appear on Out")
+			}
+
+			// канал вмещает один батч, второй отбрасывается
+			for _, m := range []string{"c", "d"} {
+				in <- m
+			}
+			deadline := time.Now().Add(time.Second)
+			for len(out) == 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			for _, m := range []string{"e", "f"} {
+				in <- m
+			}
+
+			deadline = time.Now().Add(time.Second)
+			for c.Stats().OutDropped == 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if dropped := c.Stats().OutDropped; dropped != 2 {
+				t.Fatalf("expected 2 dropped messages, got %d", dropped)
+			}
+
+			select {
+			case batch := <-out:
+				if !slices.Equal(batch, tc.kept) {
+					t.Fatalf("expected batch %v, got %v", tc.kept, batch)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("buffered batch did not appear on Out")
+			}
+
+			select {
+			case m := <-c.DLQ():
+				t.Fatalf("записанный батч не должен попадать в DLQ, got %v", m)
+			default:
+			}
+
+			// без читателя Close не зависает на переполненном Out
+			for _, m := range []string{"g", "h", "i", "j"} {
+				in <- m
+			}
+			closed := make(chan struct{})
+			go func() {
+				_ = c.Close()
+				close(closed)
+			}()
+			select {
+			case <-closed:
+			case <-time.After(time.Second):
+				t.Fatal("Close blocked on full Out")
+			}
+		})
+	}
+}
+
+// TestDedupKey проверяет, что повтор ключа в пределах окна отбрасывается,
+// а ключ, вытесненный из окна, снова принимается
+func TestDedupKey(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flushed := make(chan []string, 1)
+	c := NewConsumer[string](ctx, func(data string) error {
+		return nil
+	}, func(ctx context.Context, buf []string) error {
+		flushed <- buf
+		return nil
+	})
+
+	if err := c.SetDedupKey(func(data string) string { return data }, 0); !errors.Is(err, ErrInvalidDedupWindow) {
+		t.Fatalf("expected ErrInvalidDedupWindow, got %v", err)
+	}
+	if err := c.SetDedupKey(func(data string) string { return data }, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = c.SetBatchSize(6)
+	_ = c.SetMode(ctx, BatchMode)
+
+	in := c.In(ctx)
+	for _, m := range []string{"a", "b", "a", "c", "d", "e", "a"} {
+		in <- m
+	}
+
+	select {
+	case buf := <-flushed:
+		if !slices.Equal(buf, []string{"a", "b", "c", "d", "e", "a"}) {
+			t.Fatalf("unexpected flushed batch %v", buf)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("flush timed out")
+	}
+
+	if got := c.Stats().Duplicates; got != 1 {
+		t.Fatalf("expected 1 duplicate, got %d", got)
+	}
+
+	_ = c.Close()
+}
+
+// TestDLQMessageJSON проверяет, что сообщение DLQ с событием
+// переживает сериализацию с сохранением текста ошибки
+func TestDLQMessageJSON(t *testing.T) {
+	original := DLQMessage[event.PageViewEvent]{
+		Message: event.PageViewEvent{
+			PageID:       "page",
+			UserID:       "user",
+			ViewDuration: 42,
+			Timestamp:    time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+			Region:       "EU",
+		},
+		Err:       ErrBufferSaturated,
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC),
+		Attempts:  3,
+	}
+
+	data, err := EncodeDLQ(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"error":"buffer saturated"`) || !strings.Contains(string(data), `"attempts":3`) {
+		t.Fatalf("expected error text in %s", data)
+	}
+
+	decoded, err := DecodeDLQ[event.PageViewEvent](data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, want := decoded.Message, original.Message
+	if got.PageID != want.PageID || got.UserID != want.UserID || got.ViewDuration != want.ViewDuration ||
+		got.Region != want.Region || !got.Timestamp.Equal(want.Timestamp) {
+		t.Fatalf("message mismatch: %+v != %+v", got, want)
+	}
+	if decoded.Err == nil || decoded.Err.Error() != original.Err.Error() {
+		t.Fatalf("error mismatch: %v", decoded.Err)
+	}
+	if !decoded.Timestamp.Equal(original.Timestamp) || decoded.Attempts != original.Attempts {
+		t.Fatalf("metadata mismatch: %+v", decoded)
+	}
+}
@@ -0,0 +1,73 @@
+package consumer
+
+import "sync"
+
+// dedupWindow множество ключей последних window принятых сообщений.
+type dedupWindow[T any] struct {
+	m     sync.Mutex
+	keyFn DedupKeyFn[T]
+	keys  []string
+	next  int
+	seen  map[string]int
+}
+
+// SetDedupKey включает отбрасывание дубликатов: сообщение, ключ которого
+// встречался среди последних window принятых сообщений, не попадает в буфер
+// и учитывается в Stats().Duplicates. nil keyFn отключает дедупликацию.
+func (c *Consumer[T]) SetDedupKey(keyFn DedupKeyFn[T], window int) error {
+	if keyFn == nil {
+		c.dedup.Store(nil)
+		return nil
+	}
+	if window <= 0 {
+		return ErrInvalidDedupWindow
+	}
+
+	c.dedup.Store(&dedupWindow[T]{
+		keyFn: keyFn,
+		keys:  make([]string, 0, window),
+		seen:  make(map[string]int, window),
+	})
+
+	return nil
+}
+
+// isDuplicate проверяет сообщение на повтор и учитывает его ключ в окне.
+func (c *Consumer[T]) isDuplicate(v T) bool {
+	d := c.dedup.Load()
+	if d == nil {
+		return false
+	}
+
+	if d.add(d.keyFn(v)) {
+		return false
+	}
+
+	c.duplicates.Add(1)
+	return true
+}
+
+// add добавляет ключ в окно, вытесняя самый старый.
+// Возвращает false, если ключ уже есть в окне.
+func (d *dedupWindow[T]) add(key string) bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.seen[key] > 0 {
+		return false
+	}
+
+	if len(d.keys) < cap(d.keys) {
+		d.keys = append(d.keys, key)
+	} else {
+		old := d.keys[d.next]
+		if d.seen[old]--; d.seen[old] == 0 {
+			delete(d.seen, old)
+		}
+		d.keys[d.next] = key
+		d.next = (d.next + 1) % len(d.keys)
+	}
+	d.seen[key]++
+
+	return true
+}
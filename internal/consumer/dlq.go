@@ -1,6 +1,68 @@
 package consumer
 
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
 type DLQMessage[T any] struct {
-	Message T
-	Err     error
+	Message   T
+	Err       error
+	Timestamp time.Time // Момент попадания сообщения в DLQ
+	Attempts  int       // Количество попыток валидации, включая повторы через Replay
+}
+
+// dlqRecord JSON-представление DLQMessage для сохранения DLQ.
+type dlqRecord[T any] struct {
+	Message   T         `json:"message"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Attempts  int       `json:"attempts"`
+}
+
+// MarshalJSON сериализует сообщение DLQ вместе с текстом ошибки.
+func (m DLQMessage[T]) MarshalJSON() ([]byte, error) {
+	record := dlqRecord[T]{
+		Message:   m.Message,
+		Timestamp: m.Timestamp,
+		Attempts:  m.Attempts,
+	}
+	if m.Err != nil {
+		record.Error = m.Err.Error()
+	}
+
+	return json.Marshal(record)
+}
+
+// UnmarshalJSON восстанавливает сообщение DLQ. Ошибка восстанавливается
+// только как текст: errors.Is с исходной ошибкой не сработает.
+func (m *DLQMessage[T]) UnmarshalJSON(data []byte) error {
+	var record dlqRecord[T]
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+
+	*m = DLQMessage[T]{
+		Message:   record.Message,
+		Timestamp: record.Timestamp,
+		Attempts:  record.Attempts,
+	}
+	if record.Error != "" {
+		m.Err = errors.New(record.Error)
+	}
+
+	return nil
+}
+
+// EncodeDLQ сериализует сообщение DLQ в JSON.
+func EncodeDLQ[T any](m DLQMessage[T]) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// DecodeDLQ восстанавливает сообщение DLQ из JSON, полученного EncodeDLQ.
+func DecodeDLQ[T any](data []byte) (DLQMessage[T], error) {
+	var m DLQMessage[T]
+	err := json.Unmarshal(data, &m)
+	return m, err
 }
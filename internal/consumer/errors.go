@@ -4,4 +4,24 @@ import "errors"
 
 var (
 	ErrInvalidBatchSize = errors.New("invalid batch size")
+	ErrInvalidByteLimit = errors.New("invalid byte limit")
+	ErrSizeFnNotFound   = errors.New("size function not found")
+
+	ErrInvalidMaxBufferLen = errors.New("invalid max buffer length")
+	ErrBufferSaturated     = errors.New("buffer saturated")
+	ErrInvalidMaxBatchAge  = errors.New("invalid max batch age")
+	ErrDLQHandlerExists    = errors.New("dlq handler already registered")
+
+	ErrInvalidFlushWorkers        = errors.New("invalid flush workers count")
+	ErrInvalidFlushOverflowPolicy = errors.New("invalid flush overflow policy")
+	ErrFlushQueueFull             = errors.New("flush queue is full")
+
+	ErrInvalidOutBuffer = errors.New("invalid out buffer size")
+	ErrOutFull          = errors.New("out channel is full")
+
+	ErrInvalidOutOverflowPolicy = errors.New("invalid out overflow policy")
+
+	ErrInvalidDedupWindow = errors.New("invalid dedup window")
+
+	ErrConsumerClosed = errors.New("consumer is closed")
 )
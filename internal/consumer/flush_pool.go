@@ -0,0 +1,161 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+)
+
+// FlushOverflowPolicy определяет поведение при переполнении очереди flush-пула.
+type FlushOverflowPolicy string
+
+const (
+	// FlushOverflowBlock ожидает освобождения места в очереди.
+	FlushOverflowBlock FlushOverflowPolicy = "block"
+	// FlushOverflowDLQ отправляет сообщения батча в DLQ с ErrFlushQueueFull.
+	FlushOverflowDLQ FlushOverflowPolicy = "dlq"
+)
+
+type flushJob[T any] struct {
+	ctx context.Context
+	buf []T
+}
+
+// flushPool ограничивает число одновременно выполняемых flushFn.
+type flushPool[T any] struct {
+	jobs    chan flushJob[T]
+	done    chan struct{}  // Закрывается при остановке пула
+	senders sync.WaitGroup // dispatchFlush, ставящие задачу в очередь пула
+	wg      sync.WaitGroup
+}
+
+func newFlushPool[T any](workers, queueSize int, run func(context.Context, []T)) *flushPool[T] {
+	p := &flushPool[T]{
+		jobs: make(chan flushJob[T], queueSize),
+		done: make(chan struct{}),
+	}
+
+	p.wg.Add(workers)
+	for range workers {
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				run(job.ctx, job.buf)
+			}
+		}()
+	}
+
+	return p
+}
+
+// stop закрывает очередь и дожидается выполнения уже поставленных задач.
+// Отправители, ожидающие места в очереди, получают сигнал done
+// и передают батч текущему пулу.
+func (p *flushPool[T]) stop() {
+	close(p.done)
+	p.senders.Wait()
+	close(p.jobs)
+	p.wg.Wait()
+}
+
+// SetFlushWorkers ограничивает число одновременных flush n воркерами.
+// Батчи сверх n ждут в очереди размером flushQueueSize, при ее переполнении
+// применяется политика SetFlushOverflowPolicy. n == 0 возвращает запуск
+// отдельной горутины на каждый flush. Предыдущий пул завершается
+// после обработки своей очереди.
+func (c *Consumer[T]) SetFlushWorkers(n int) error {
+	if n < 0 {
+		return ErrInvalidFlushWorkers
+	}
+
+	var pool *flushPool[T]
+	if n > 0 {
+		pool = newFlushPool(n, flushQueueSize, c.runFlush)
+	}
+
+	c.replaceFlushPool(pool)
+
+	return nil
+}
+
+// replaceFlushPool устанавливает новый пул и дожидается завершения предыдущего.
+func (c *Consumer[T]) replaceFlushPool(pool *flushPool[T]) {
+	c.flushMu.Lock()
+	prev := c.flushPool
+	c.flushPool = pool
+	c.flushMu.Unlock()
+
+	if prev != nil {
+		prev.stop()
+	}
+}
+
+// SetFlushOverflowPolicy задает поведение при переполнении очереди flush-пула.
+// По умолчанию используется FlushOverflowBlock.
+func (c *Consumer[T]) SetFlushOverflowPolicy(policy FlushOverflowPolicy) error {
+	if policy != FlushOverflowBlock && policy != FlushOverflowDLQ {
+		return ErrInvalidFlushOverflowPolicy
+	}
+
+	c.flushMu.Lock()
+	c.flushOverflow = policy
+	c.flushMu.Unlock()
+
+	return nil
+}
+
+// dispatchFlush передает батч пулу или, если пул не задан, отдельной горутине.
+// Ожидание места в очереди выполняется без блокировки flushMu,
+// поэтому SetFlushWorkers и Close не ждут освобождения заполненной очереди.
+func (c *Consumer[T]) dispatchFlush(ctx context.Context, buf []T) {
+	job := flushJob[T]{ctx: ctx, buf: buf}
+
+	for {
+		c.flushMu.RLock()
+		pool, policy := c.flushPool, c.flushOverflow
+		if pool != nil {
+			pool.senders.Add(1)
+		}
+		c.flushMu.RUnlock()
+
+		if pool == nil {
+			go c.runFlush(ctx, buf)
+			return
+		}
+
+		if policy == FlushOverflowDLQ {
+			select {
+			case pool.jobs <- job:
+			default:
+				c.logger.Error(ErrFlushQueueFull.Error())
+				for _, v := range buf {
+					c.toDLQ(v, ErrFlushQueueFull, validationAttempts)
+				}
+			}
+			pool.senders.Done()
+			return
+		}
+
+		select {
+		case pool.jobs <- job:
+			pool.senders.Done()
+			return
+		case <-pool.done:
+			// пул заменен или остановлен, передаем батч текущему
+			pool.senders.Done()
+		}
+	}
+}
+
+// runFlush записывает батч через flushFn и при успехе
+// уведомляет слушателей и передает батч в Out.
+func (c *Consumer[T]) runFlush(ctx context.Context, buf []T) {
+	if c.flushFn != nil {
+		if err := c.flushFn(ctx, buf); err != nil {
+			c.logger.Error(err.Error())
+			return
+		}
+	}
+
+	c.callFlushSuccessListeners(len(buf))
+	c.publishOut(buf)
+}
@@ -3,9 +3,10 @@ package consumer
 type Mode string
 
 const (
-	BatchMode  Mode = "batch"
-	TimeMode        = "time"
-	HybridMode      = "hybrid"
+	BatchMode     Mode = "batch"
+	TimeMode           = "time"
+	HybridMode         = "hybrid"
+	SizeBytesMode      = "size_bytes"
 )
 
 const (
@@ -0,0 +1,34 @@
+package consumer
+
+import (
+	"ay-events-generator/internal/clock"
+
+	"go.uber.org/zap"
+)
+
+// Option настраивает Consumer при создании.
+type Option[T any] func(*Consumer[T])
+
+// WithClock задает источник времени для таймеров Consumer.
+// По умолчанию используются реальные часы.
+func WithClock[T any](clk clock.Clock) Option[T] {
+	return func(c *Consumer[T]) {
+		c.clock = clk
+	}
+}
+
+// WithLogger задает логгер Consumer.
+// По умолчанию используется глобальный zap.L().
+func WithLogger[T any](logger *zap.Logger) Option[T] {
+	return func(c *Consumer[T]) {
+		c.logger = logger
+	}
+}
+
+// WithSizeFn задает функцию оценки размера сообщения в байтах,
+// необходимую для режима SizeBytesMode.
+func WithSizeFn[T any](fn SizeFn[T]) Option[T] {
+	return func(c *Consumer[T]) {
+		c.sizeFn = fn
+	}
+}
@@ -0,0 +1,89 @@
+package consumer
+
+// OutOverflowPolicy определяет, какой батч отбрасывается при заполненном канале Out.
+type OutOverflowPolicy string
+
+const (
+	// OutDropNewest отбрасывает новый батч, сохраняя уже ожидающие в канале.
+	OutDropNewest OutOverflowPolicy = "drop_newest"
+	// OutDropOldest вытесняет самый старый батч из канала, освобождая место новому.
+	OutDropOldest OutOverflowPolicy = "drop_oldest"
+)
+
+// SetOutBuffer задает размер буфера канала Out и политику при его заполнении.
+// Передача в Out никогда не блокирует flush: лишние батчи отбрасываются,
+// логируются с ErrOutFull и учитываются в Stats().OutDropped.
+// Батчи уже записаны flushFn, поэтому в DLQ они не попадают.
+// Должен вызываться до Out.
+func (c *Consumer[T]) SetOutBuffer(n int, policy OutOverflowPolicy) error {
+	if n <= 0 {
+		return ErrInvalidOutBuffer
+	}
+	if policy != OutDropNewest && policy != OutDropOldest {
+		return ErrInvalidOutOverflowPolicy
+	}
+
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+
+	c.out = make(chan []T, n)
+	c.outOverflow = policy
+
+	return nil
+}
+
+// Out возвращает канал успешно записанных батчей для построения
+// многоступенчатых конвейеров. Батч попадает в канал после того, как flushFn
+// вернул nil; если flushFn не задан, в канал попадает каждый батч.
+// Без SetOutBuffer используется буфер outBufferSize с политикой OutDropNewest,
+// чтобы медленный читатель не останавливал flush. Канал не закрывается.
+func (c *Consumer[T]) Out() <-chan []T {
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+
+	if c.out == nil {
+		c.out = make(chan []T, outBufferSize)
+		c.outOverflow = OutDropNewest
+	}
+
+	return c.out
+}
+
+// publishOut передает батч в канал Out, если он был запрошен.
+// Не блокируется: при заполненном канале один батч отбрасывается по политике.
+// outMu удерживается на время передачи, чтобы вытеснение не гонялось
+// с другими flush; все операции с каналом неблокирующие.
+func (c *Consumer[T]) publishOut(buf []T) {
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+
+	if c.out == nil {
+		return
+	}
+
+	for {
+		select {
+		case c.out <- buf:
+			return
+		default:
+		}
+
+		if c.outOverflow == OutDropNewest {
+			c.dropOut(buf)
+			return
+		}
+
+		select {
+		case oldest := <-c.out:
+			c.dropOut(oldest)
+		default:
+			// читатель освободил место, повторяем отправку
+		}
+	}
+}
+
+// dropOut учитывает отброшенный батч.
+func (c *Consumer[T]) dropOut(buf []T) {
+	c.outDropped.Add(uint64(len(buf)))
+	c.logger.Error(ErrOutFull.Error())
+}
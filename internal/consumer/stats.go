@@ -0,0 +1,15 @@
+package consumer
+
+// Stats снимок счетчиков Consumer.
+type Stats struct {
+	Duplicates uint64 // Сообщения, отброшенные дедупликацией SetDedupKey
+	OutDropped uint64 // Сообщения записанных батчей, не попавшие в переполненный Out
+}
+
+// Stats возвращает снимок счетчиков Consumer.
+func (c *Consumer[T]) Stats() Stats {
+	return Stats{
+		Duplicates: c.duplicates.Load(),
+		OutDropped: c.outDropped.Load(),
+	}
+}
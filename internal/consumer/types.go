@@ -5,3 +5,13 @@ import "context"
 type ValidMessageFn[T any] = func(data T) error
 
 type FlushFn[T any] = func(context.Context, []T) error
+
+type SizeFn[T any] = func(data T) int
+
+type DLQHandler[T any] = func(message DLQMessage[T])
+
+// DedupKeyFn извлекает из сообщения ключ дедупликации.
+type DedupKeyFn[T any] = func(data T) string
+
+// FlushSuccessListener получает размер батча, успешно записанного flushFn.
+type FlushSuccessListener = func(count int)
@@ -3,6 +3,7 @@ package context_merge
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 // Merge объединяет несколько context.Context в один.
@@ -11,27 +12,77 @@ import (
 func Merge(ctxs ...context.Context) (context.Context, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	doneChannels := make([]<-chan struct{}, len(ctxs)+1)
+	doneChannels := make([]<-chan struct{}, 0, len(ctxs))
 	for _, c := range ctxs {
 		doneChannels = append(doneChannels, c.Done())
 	}
-	doneChannels = append(doneChannels, ctx.Done())
 
-	doneCh := fanIn[struct{}](doneChannels...)
+	doneCh := fanIn[struct{}](ctx.Done(), doneChannels...)
 
 	go func() {
-		<-doneCh
-		cancel()
+		select {
+		case <-doneCh:
+			cancel()
+		case <-ctx.Done():
+		}
 	}()
 
 	return ctx, cancel
 }
 
+// MergeWithPrimary объединяет контексты так же, как Merge, но значения
+// результирующего контекста берутся только из primary.
+// Отмена происходит при отмене primary или любого из others.
+// Deadline результирующего контекста равен самому раннему из deadline входных контекстов.
+func MergeWithPrimary(primary context.Context, others ...context.Context) (context.Context, context.CancelFunc) {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if deadline, ok := earliestDeadline(append([]context.Context{primary}, others...)); ok {
+		ctx, cancel = context.WithDeadline(context.WithoutCancel(primary), deadline)
+	} else {
+		ctx, cancel = context.WithCancel(context.WithoutCancel(primary))
+	}
+
+	doneChannels := make([]<-chan struct{}, 0, len(others)+1)
+	doneChannels = append(doneChannels, primary.Done())
+	for _, c := range others {
+		doneChannels = append(doneChannels, c.Done())
+	}
+
+	doneCh := fanIn[struct{}](ctx.Done(), doneChannels...)
+
+	go func() {
+		select {
+		case <-doneCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// earliestDeadline возвращает самый ранний deadline среди контекстов.
+// ok равен false, если ни у одного контекста deadline не задан.
+func earliestDeadline(ctxs []context.Context) (deadline time.Time, ok bool) {
+	for _, c := range ctxs {
+		d, has := c.Deadline()
+		if has && (!ok || d.Before(deadline)) {
+			deadline, ok = d, true
+		}
+	}
+
+	return deadline, ok
+}
+
 // fanIn ожидает срабатывания любого из переданных каналов.
 // При первом получении сигнала (закрытии или получении значения)
 // закрывает результирующий канал result.
-// Остальные горутины завершаются после закрытия result.
-func fanIn[T any](chs ...<-chan T) chan T {
+// Остальные горутины завершаются после закрытия result или done.
+// Закрытие done останавливает fan-in без закрытия result.
+func fanIn[T any](done <-chan struct{}, chs ...<-chan T) chan T {
 	var once sync.Once
 
 	result := make(chan T)
@@ -39,6 +90,8 @@ func fanIn[T any](chs ...<-chan T) chan T {
 	for _, ch := range chs {
 		go func(channel <-chan T) {
 			select {
+			case <-done:
+				return
 			case <-result:
 				return
 			case <-channel:
@@ -2,6 +2,7 @@ package context_merge
 
 import (
 	"context"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -72,3 +73,109 @@ func TestMerge_MultipleContexts(t *testing.T) {
 		t.Fatal("merged context was not canceled when one of multiple contexts was canceled")
 	}
 }
+
+type ctxKey string
+
+func TestMergeWithPrimary_ValuesFromPrimaryOnly(t *testing.T) {
+	primary := context.WithValue(context.Background(), ctxKey("request"), "primary")
+	other := context.WithValue(context.Background(), ctxKey("request"), "other")
+	other = context.WithValue(other, ctxKey("only-other"), "other")
+
+	merged, cancel := MergeWithPrimary(primary, other)
+	defer cancel()
+
+	if v := merged.Value(ctxKey("request")); v != "primary" {
+		t.Fatalf("expected value from primary context, got %v", v)
+	}
+	if v := merged.Value(ctxKey("only-other")); v != nil {
+		t.Fatalf("expected no value from other contexts, got %v", v)
+	}
+}
+
+func TestMergeWithPrimary_CancelWhenAnyContextCanceled(t *testing.T) {
+	for name, cancelIndex := range map[string]int{"primary": 0, "other": 1} {
+		t.Run(name, func(t *testing.T) {
+			primary, cancelPrimary := context.WithCancel(context.Background())
+			other, cancelOther := context.WithCancel(context.Background())
+			defer cancelPrimary()
+			defer cancelOther()
+
+			merged, cancel := MergeWithPrimary(primary, other)
+			defer cancel()
+
+			[]context.CancelFunc{cancelPrimary, cancelOther}[cancelIndex]()
+
+			select {
+			case <-merged.Done():
+				// expected
+			case <-time.After(100 * time.Millisecond):
+				t.Fatal("merged context was not canceled when one of contexts was canceled")
+			}
+		})
+	}
+}
+
+// TestMergeWithPrimary_EarliestDeadline проверяет, что результирующий контекст
+// получает самый ранний deadline среди primary и others
+func TestMergeWithPrimary_EarliestDeadline(t *testing.T) {
+	now := time.Now()
+	primary, cancelPrimary := context.WithDeadline(context.Background(), now.Add(time.Hour))
+	early, cancelEarly := context.WithDeadline(context.Background(), now.Add(50*time.Millisecond))
+	defer cancelPrimary()
+	defer cancelEarly()
+
+	merged, cancel := MergeWithPrimary(primary, context.Background(), early)
+	defer cancel()
+
+	deadline, ok := merged.Deadline()
+	if !ok || !deadline.Equal(now.Add(50*time.Millisecond)) {
+		t.Fatalf("expected deadline %v, got %v (ok=%v)", now.Add(50*time.Millisecond), deadline, ok)
+	}
+
+	select {
+	case <-merged.Done():
+		// expected
+	case <-time.After(time.Second):
+		t.Fatal("merged context was not canceled at the earliest deadline")
+	}
+
+	plain, cancelPlain := MergeWithPrimary(context.Background(), context.Background())
+	defer cancelPlain()
+
+	if _, ok := plain.Deadline(); ok {
+		t.Fatal("expected no deadline when no context has one")
+	}
+}
+
+// TestFanIn_DoneStopsGoroutines проверяет, что закрытие done завершает
+// все горутины fan-in, даже если ни один из каналов не сработал
+func TestFanIn_DoneStopsGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	done := make(chan struct{})
+	chs := make([]<-chan struct{}, 10)
+	for i := range chs {
+		chs[i] = make(chan struct{})
+	}
+
+	result := fanIn(done, chs...)
+	if got := runtime.NumGoroutine(); got < before+len(chs) {
+		t.Fatalf("expected at least %d goroutines, got %d", before+len(chs), got)
+	}
+
+	close(done)
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := runtime.NumGoroutine(); got > before {
+		t.Fatalf("fan-in goroutines leaked: before %d, after %d", before, got)
+	}
+
+	select {
+	case <-result:
+		t.Fatal("result must not be closed by done")
+	default:
+	}
+}
@@ -1,18 +1,52 @@
 package dispatcher
 
 import (
+	"ay-events-generator/internal/clock"
 	"context"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
-type Dispatcher struct{}
+type Dispatcher struct {
+	clock              clock.Clock
+	logger             *zap.Logger
+	attemptListeners   []AttemptListener
+	exhaustedListeners []ExhaustedListener
+	listenersMutex     sync.RWMutex
+}
 
 // NewDispatcher создает и возвращает новый экземпляр Dispatcher.
-// Используется для инициализации диспетчера без дополнительной конфигурации.
-func NewDispatcher() *Dispatcher {
-	return &Dispatcher{}
+// Без опций используется конфигурация по умолчанию.
+func NewDispatcher(opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		clock:  clock.Real(),
+		logger: zap.L(),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// AddAttemptListener добавляет слушателя неудачных попыток записи.
+// Слушатель получает номер попытки (начиная с 1) и ее ошибку.
+func (d *Dispatcher) AddAttemptListener(fn AttemptListener) {
+	d.listenersMutex.Lock()
+	defer d.listenersMutex.Unlock()
+
+	d.attemptListeners = append(d.attemptListeners, fn)
+}
+
+// AddExhaustedListener добавляет слушателя исчерпания попыток записи.
+func (d *Dispatcher) AddExhaustedListener(fn ExhaustedListener) {
+	d.listenersMutex.Lock()
+	defer d.listenersMutex.Unlock()
+
+	d.exhaustedListeners = append(d.exhaustedListeners, fn)
 }
 
 // Write выполняет запись с использованием механизма повторных попыток (backoff).
@@ -28,13 +62,14 @@ func (d *Dispatcher) Write(ctx context.Context, writeFn WriteFn) error {
 func (d *Dispatcher) writeWithBackoff(ctx context.Context, writeFn WriteFn) error {
 	timeout := startBackoffTimeout
 
-	for range backoffAttemptCount {
+	for attempt := range backoffAttemptCount {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
 			if err := d.singleWrite(ctx, timeout, writeFn); err != nil {
-				zap.L().Error(err.Error())
+				d.logger.Error(err.Error())
+				d.callAttemptListeners(attempt+1, err)
 				timeout = time.Duration(float64(timeout) * backoffMultiply)
 				continue
 			}
@@ -43,18 +78,39 @@ func (d *Dispatcher) writeWithBackoff(ctx context.Context, writeFn WriteFn) erro
 		return nil
 	}
 
+	d.callExhaustedListeners(ErrBackoffTimeout)
 	return ErrBackoffTimeout
 }
 
+func (d *Dispatcher) callAttemptListeners(attempt int, err error) {
+	d.listenersMutex.RLock()
+	listeners := d.attemptListeners[:len(d.attemptListeners):len(d.attemptListeners)]
+	d.listenersMutex.RUnlock()
+
+	for _, fn := range listeners {
+		fn(attempt, err)
+	}
+}
+
+func (d *Dispatcher) callExhaustedListeners(err error) {
+	d.listenersMutex.RLock()
+	listeners := d.exhaustedListeners[:len(d.exhaustedListeners):len(d.exhaustedListeners)]
+	d.listenersMutex.RUnlock()
+
+	for _, fn := range listeners {
+		fn(err)
+	}
+}
+
 // singleWrite выполняет одну попытку записи с ограничением по времени.
 // Создает дочерний контекст с таймаутом и вызывает переданную функцию writeFn.
 // В случае ошибки логирует её и возвращает вызывающему коду.
 func (d *Dispatcher) singleWrite(ctx context.Context, timeout time.Duration, writeFn WriteFn) error {
-	ctxT, cancel := context.WithTimeout(ctx, timeout)
+	ctxT, cancel := clock.WithTimeout(ctx, d.clock, timeout)
 	defer cancel()
 
 	if err := writeFn(ctxT); err != nil {
-		zap.L().Error(err.Error())
+		d.logger.Error(err.Error())
 		return err
 	}
 
@@ -1,8 +1,10 @@
 package dispatcher
 
 import (
+	"ay-events-generator/internal/clock"
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -48,6 +50,93 @@ func TestDispatcher_BackoffRetry(t *testing.T) {
 	}
 }
 
+func TestDispatcher_AttemptListener(t *testing.T) {
+	var called int32
+	failures := 2
+	w := func(ctx context.Context) error {
+		c := atomic.AddInt32(&called, 1)
+		if c <= int32(failures) {
+			return errors.New("fail")
+		}
+		return nil
+	}
+
+	var attempts []int
+	exhausted := false
+
+	d := NewDispatcher()
+	d.AddAttemptListener(func(attempt int, err error) {
+		if err == nil {
+			t.Error("expected attempt error, got nil")
+		}
+		attempts = append(attempts, attempt)
+	})
+	d.AddExhaustedListener(func(err error) {
+		exhausted = true
+	})
+
+	if err := d.Write(context.Background(), w); err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != failures || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("expected attempts [1 2], got %v", attempts)
+	}
+	if exhausted {
+		t.Error("expected exhausted listener not to be called")
+	}
+}
+
+func TestDispatcher_ExhaustedListener(t *testing.T) {
+	w := func(ctx context.Context) error {
+		return errors.New("fail")
+	}
+
+	var attempts, exhausted int
+
+	d := NewDispatcher()
+	d.AddAttemptListener(func(attempt int, err error) {
+		attempts++
+	})
+	d.AddExhaustedListener(func(err error) {
+		if !errors.Is(err, ErrBackoffTimeout) {
+			t.Errorf("expected ErrBackoffTimeout, got %v", err)
+		}
+		exhausted++
+	})
+
+	if err := d.Write(context.Background(), w); !errors.Is(err, ErrBackoffTimeout) {
+		t.Fatalf("expected ErrBackoffTimeout, got %v", err)
+	}
+	if attempts != backoffAttemptCount {
+		t.Errorf("expected %d attempts, got %d", backoffAttemptCount, attempts)
+	}
+	if exhausted != 1 {
+		t.Errorf("expected exhausted listener to be called once, got %d", exhausted)
+	}
+}
+
+func TestDispatcher_ConcurrentAddListener(t *testing.T) {
+	d := NewDispatcher()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			d.AddAttemptListener(func(attempt int, err error) {})
+			d.AddExhaustedListener(func(err error) {})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			d.callAttemptListeners(1, errors.New("fail"))
+			d.callExhaustedListeners(ErrBackoffTimeout)
+		}
+	}()
+	wg.Wait()
+}
+
 func TestDispatcher_ContextCancel(t *testing.T) {
 	var called int32
 	w := func(ctx context.Context) error {
@@ -69,3 +158,57 @@ func TestDispatcher_ContextCancel(t *testing.T) {
 		t.Errorf("expected at least one call to writer")
 	}
 }
+
+// TestDispatcher_BackoffIntervalsFakeClock проверяет таймауты попыток
+// (1s, 1.2s, 1.44s, ...) на управляемых часах без реального ожидания.
+func TestDispatcher_BackoffIntervalsFakeClock(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	started := make(chan time.Time, backoffAttemptCount)
+
+	w := func(ctx context.Context) error {
+		started <- clk.Now()
+		<-ctx.Done()
+		return context.Cause(ctx)
+	}
+
+	d := NewDispatcher(WithClock(clk))
+
+	result := make(chan error, 1)
+	go func() {
+		result <- d.Write(context.Background(), w)
+	}()
+
+	expected := []time.Duration{
+		time.Second,
+		1200 * time.Millisecond,
+		1440 * time.Millisecond,
+		1728 * time.Millisecond,
+		2073600 * time.Microsecond,
+	}
+
+	var starts []time.Time
+	for _, interval := range expected {
+		starts = append(starts, <-started)
+
+		clk.BlockUntil(1)
+		clk.Advance(interval - time.Millisecond)
+		select {
+		case <-started:
+			t.Fatalf("attempt timed out before %v", interval)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		clk.Advance(time.Millisecond)
+	}
+
+	if err := <-result; !errors.Is(err, ErrBackoffTimeout) {
+		t.Fatalf("expected ErrBackoffTimeout, got %v", err)
+	}
+
+	for i := 1; i < len(starts); i++ {
+		got := starts[i].Sub(starts[i-1])
+		if diff := got - expected[i-1]; diff < -time.Microsecond || diff > time.Microsecond {
+			t.Errorf("attempt %d: expected timeout %v, got %v", i, expected[i-1], got)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package dispatcher
+
+import (
+	"ay-events-generator/internal/clock"
+
+	"go.uber.org/zap"
+)
+
+// Option настраивает Dispatcher при создании.
+type Option func(*Dispatcher)
+
+// WithClock задает источник времени для таймаутов попыток записи.
+// По умолчанию используются реальные часы.
+func WithClock(clk clock.Clock) Option {
+	return func(d *Dispatcher) {
+		d.clock = clk
+	}
+}
+
+// WithLogger задает логгер Dispatcher.
+// По умолчанию используется глобальный zap.L().
+func WithLogger(logger *zap.Logger) Option {
+	return func(d *Dispatcher) {
+		d.logger = logger
+	}
+}
@@ -3,3 +3,9 @@ package dispatcher
 import "context"
 
 type WriteFn = func(ctx context.Context) error
+
+// AttemptListener вызывается после каждой неудачной попытки записи
+type AttemptListener = func(attempt int, err error)
+
+// ExhaustedListener вызывается, когда все попытки записи исчерпаны
+type ExhaustedListener = func(err error)
@@ -0,0 +1,15 @@
+package event
+
+import "errors"
+
+var (
+	ErrInvalidSchema    = errors.New("invalid schema")
+	ErrSchemaMismatch   = errors.New("schema mismatch")
+	ErrEmptyPageID      = errors.New("empty page id")
+	ErrNegativeDuration = errors.New("negative view duration")
+	ErrZeroDuration     = errors.New("zero view duration")
+	ErrInvalidUTF8      = errors.New("invalid utf-8 in string field")
+
+	ErrInvalidTimestamp         = errors.New("invalid timestamp")
+	ErrInvalidTimestampEncoding = errors.New("invalid timestamp encoding")
+)
@@ -16,8 +16,20 @@ type PageViewEvent struct {
 	IPAddress    string    `json:"ip_address,omitempty"`
 	Region       string    `json:"region,omitempty"`
 	IsBounce     bool      `json:"is_bounce"`
+
+	// Headers передаются в заголовках Kafka-сообщения и не входят в JSON
+	Headers map[string]string `json:"-"`
+}
+
+// PartitionKey возвращает канонический ключ события для Kafka.
+// Сообщения с одинаковым ключом попадают в одну партицию и сохраняют порядок,
+// поэтому все отправители и партиционеры должны использовать именно его.
+func (e *PageViewEvent) PartitionKey() string {
+	return e.UserID
 }
 
+// Bytes сериализует событие в JSON.
+// Некорректный UTF-8 в строках заменяется на U+FFFD, как в json.Marshal.
 func (e *PageViewEvent) Bytes() ([]byte, error) {
 	b, err := json.Marshal(e)
 	if err != nil {
@@ -27,6 +39,47 @@ func (e *PageViewEvent) Bytes() ([]byte, error) {
 	return b, nil
 }
 
+// BytesStrict сериализует событие в JSON, как Bytes, но строки
+// с некорректным UTF-8 не заменяются молча, а приводят к ErrInvalidUTF8.
+func (e *PageViewEvent) BytesStrict() ([]byte, error) {
+	if err := e.validateUTF8(); err != nil {
+		zap.L().Error(err.Error())
+		return nil, err
+	}
+
+	return e.Bytes()
+}
+
+// MarshalMasked сериализует событие, исключая из JSON перечисленные поля.
+// Поля задаются JSON-именами, например "ip_address" или "user_agent".
+func (e *PageViewEvent) MarshalMasked(fields ...string) ([]byte, error) {
+	b, err := e.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return b, nil
+	}
+
+	var doc map[string]json.RawMessage
+	if err = json.Unmarshal(b, &doc); err != nil {
+		zap.L().Error(err.Error())
+		return nil, err
+	}
+
+	for _, field := range fields {
+		delete(doc, field)
+	}
+
+	b, err = json.Marshal(doc)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return nil, err
+	}
+	return b, nil
+}
+
 func (e *PageViewEvent) String() (string, error) {
 	b, err := e.Bytes()
 	if err != nil {
@@ -0,0 +1,148 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPartitionKey_IsUserID(t *testing.T) {
+	e := validEvent()
+
+	if e.PartitionKey() != e.UserID {
+		t.Fatalf("expected partition key %q, got %q", e.UserID, e.PartitionKey())
+	}
+}
+
+func TestPartitionKey_IgnoresNonKeyFields(t *testing.T) {
+	e1 := validEvent()
+	e2 := validEvent()
+	e2.PageID = "another-page"
+	e2.ViewDuration = 42
+	e2.Region = "US"
+
+	if e1.PartitionKey() != e2.PartitionKey() {
+		t.Fatal("events of the same user must share the partition key")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := validEvent()
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid event, got %v", err)
+	}
+
+	emptyPageID := validEvent()
+	emptyPageID.PageID = ""
+	if err := emptyPageID.Validate(); !errors.Is(err, ErrEmptyPageID) {
+		t.Fatalf("expected ErrEmptyPageID, got %v", err)
+	}
+
+	negativeDuration := validEvent()
+	negativeDuration.ViewDuration = -10
+	if err := negativeDuration.Validate(); !errors.Is(err, ErrNegativeDuration) {
+		t.Fatalf("expected ErrNegativeDuration, got %v", err)
+	}
+
+	zeroDuration := validEvent()
+	zeroDuration.ViewDuration = 0
+	if err := zeroDuration.Validate(); !errors.Is(err, ErrZeroDuration) {
+		t.Fatalf("expected ErrZeroDuration, got %v", err)
+	}
+
+	invalidUTF8 := validEvent()
+	invalidUTF8.UserAgent = string([]byte{0xff, 0xfe, 0xfd})
+	if err := invalidUTF8.Validate(); !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("expected ErrInvalidUTF8, got %v", err)
+	}
+	if _, err := invalidUTF8.BytesStrict(); !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("expected ErrInvalidUTF8 from BytesStrict, got %v", err)
+	}
+	if _, err := invalidUTF8.Bytes(); err != nil {
+		t.Fatalf("expected Bytes to replace invalid UTF-8, got %v", err)
+	}
+}
+
+func TestMarshalMasked(t *testing.T) {
+	e := validEvent()
+	e.IPAddress = "10.0.0.1"
+	e.UserAgent = "Mozilla/5.0"
+
+	b, err := e.MarshalMasked("ip_address")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]any
+	if err = json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := doc["ip_address"]; ok {
+		t.Fatal("expected ip_address to be omitted")
+	}
+	if doc["user_agent"] != e.UserAgent || doc["page_id"] != e.PageID || doc["user_id"] != e.UserID {
+		t.Fatalf("expected other fields to remain, got %v", doc)
+	}
+	if e.IPAddress != "10.0.0.1" {
+		t.Fatal("masking must not modify the event")
+	}
+}
+
+func TestTimestampEncoding(t *testing.T) {
+	t.Cleanup(func() { _ = SetTimestampEncoding(TimestampRFC3339Nano) })
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 678_000_000, time.UTC)
+
+	tests := []struct {
+		enc  TimestampEncoding
+		json string
+		want time.Time
+	}{
+		{TimestampRFC3339Nano, `"2024-01-02T03:04:05.678Z"`, ts},
+		{TimestampRFC3339, `"2024-01-02T03:04:05Z"`, ts.Truncate(time.Second)},
+		{TimestampUnixSeconds, `1704164645`, ts.Truncate(time.Second)},
+		{TimestampUnixMillis, `1704164645678`, ts},
+	}
+
+	for _, tt := range tests {
+		if err := SetTimestampEncoding(tt.enc); err != nil {
+			t.Fatal(err)
+		}
+
+		e := validEvent()
+		e.Timestamp = ts
+
+		b, err := e.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var doc map[string]json.RawMessage
+		if err = json.Unmarshal(b, &doc); err != nil {
+			t.Fatal(err)
+		}
+		if string(doc["timestamp"]) != tt.json {
+			t.Fatalf("encoding %d: expected timestamp %s, got %s", tt.enc, tt.json, doc["timestamp"])
+		}
+		if _, ok := doc["page_id"]; !ok {
+			t.Fatalf("encoding %d: expected other fields in JSON, got %s", tt.enc, b)
+		}
+
+		var decoded PageViewEvent
+		if err = json.Unmarshal(b, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Timestamp.Equal(tt.want) {
+			t.Fatalf("encoding %d: expected round-trip timestamp %v, got %v", tt.enc, tt.want, decoded.Timestamp)
+		}
+		if decoded.PageID != e.PageID || decoded.UserID != e.UserID || decoded.ViewDuration != e.ViewDuration {
+			t.Fatalf("encoding %d: fields lost in round-trip: %+v", tt.enc, decoded)
+		}
+	}
+
+	if err := SetTimestampEncoding(TimestampEncoding(42)); !errors.Is(err, ErrInvalidTimestampEncoding) {
+		t.Fatalf("expected ErrInvalidTimestampEncoding, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// schemaNode подмножество JSON Schema, достаточное для описания контракта события:
+// type, required, properties, enum, minLength/maxLength и minimum/maximum.
+// Остальные ключевые слова проверки не поддерживаются и делают схему некорректной,
+// чтобы контракт не считался выполненным из-за молча пропущенного ограничения.
+type schemaNode struct {
+	Type       schemaType             `json:"type,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+	Properties map[string]*schemaNode `json:"properties,omitempty"`
+	Enum       []any                  `json:"enum,omitempty"`
+	MinLength  *int                   `json:"minLength,omitempty"`
+	MaxLength  *int                   `json:"maxLength,omitempty"`
+	Minimum    *float64               `json:"minimum,omitempty"`
+	Maximum    *float64               `json:"maximum,omitempty"`
+}
+
+// schemaKeywords ключевые слова, которые понимает schemaNode.
+// Аннотации ($schema, title и т.п.) на проверку не влияют и допускаются.
+var schemaKeywords = map[string]bool{
+	"type": true, "required": true, "properties": true, "enum": true,
+	"minLength": true, "maxLength": true, "minimum": true, "maximum": true,
+
+	"$schema": true, "$id": true, "$comment": true,
+	"title": true, "description": true, "default": true, "examples": true,
+}
+
+// UnmarshalJSON разбирает узел схемы, отклоняя неподдерживаемые ключевые слова.
+func (n *schemaNode) UnmarshalJSON(data []byte) error {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return err
+	}
+	for keyword := range keywords {
+		if !schemaKeywords[keyword] {
+			return fmt.Errorf("%w: unsupported keyword %q", ErrInvalidSchema, keyword)
+		}
+	}
+
+	type node schemaNode
+	return json.Unmarshal(data, (*node)(n))
+}
+
+// schemaType значение type: одно имя типа или список допустимых типов.
+type schemaType []string
+
+// UnmarshalJSON принимает как "string", так и ["string", "null"].
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = schemaType{name}
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*t = names
+	return nil
+}
+
+// SchemaValidator проверяет сериализованный PageViewEvent на соответствие JSON Schema.
+type SchemaValidator struct {
+	root *schemaNode
+}
+
+// NewSchemaValidator разбирает документ JSON Schema и создает валидатор.
+// Возвращает ErrInvalidSchema, если документ некорректен или использует
+// ключевые слова, которые валидатор не проверяет.
+func NewSchemaValidator(schema []byte) (*SchemaValidator, error) {
+	var root schemaNode
+	if err := json.Unmarshal(schema, &root); err != nil {
+		zap.L().Error(err.Error())
+		if errors.Is(err, ErrInvalidSchema) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+
+	return &SchemaValidator{root: &root}, nil
+}
+
+// Validate сериализует событие и проверяет полученный JSON на соответствие схеме.
+// Может использоваться как ValidMessageFn для Consumer.
+func (v *SchemaValidator) Validate(e *PageViewEvent) error {
+	b, err := e.Bytes()
+	if err != nil {
+		return err
+	}
+
+	var doc any
+	if err = json.Unmarshal(b, &doc); err != nil {
+		zap.L().Error(err.Error())
+		return err
+	}
+
+	return v.root.validate("$", doc)
+}
+
+// validate рекурсивно проверяет значение по узлу схемы.
+// path используется для формирования понятного сообщения об ошибке.
+func (n *schemaNode) validate(path string, value any) error {
+	if len(n.Type) > 0 && !slices.ContainsFunc(n.Type, func(t string) bool { return matchType(t, value) }) {
+		return fmt.Errorf("%w: %s: expected %s", ErrSchemaMismatch, path, strings.Join(n.Type, " or "))
+	}
+
+	if len(n.Enum) > 0 && !slices.Contains(n.Enum, value) {
+		return fmt.Errorf("%w: %s: value is not in enum", ErrSchemaMismatch, path)
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n.MinLength != nil && length < *n.MinLength {
+			return fmt.Errorf("%w: %s: shorter than %d", ErrSchemaMismatch, path, *n.MinLength)
+		}
+		if n.MaxLength != nil && length > *n.MaxLength {
+			return fmt.Errorf("%w: %s: longer than %d", ErrSchemaMismatch, path, *n.MaxLength)
+		}
+	case float64:
+		if n.Minimum != nil && v < *n.Minimum {
+			return fmt.Errorf("%w: %s: less than %v", ErrSchemaMismatch, path, *n.Minimum)
+		}
+		if n.Maximum != nil && v > *n.Maximum {
+			return fmt.Errorf("%w: %s: greater than %v", ErrSchemaMismatch, path, *n.Maximum)
+		}
+	case map[string]any:
+		for _, name := range n.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%w: %s: missing required property %q", ErrSchemaMismatch, path, name)
+			}
+		}
+		for name, property := range n.Properties {
+			field, ok := v[name]
+			if !ok {
+				continue
+			}
+			if err := property.validate(path+"."+name, field); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchType проверяет соответствие значения типу JSON Schema.
+func matchType(t string, value any) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,132 @@
+package event
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const pageViewSchema = `{
+	"type": "object",
+	"required": ["page_id", "user_id", "view_duration_ms", "timestamp"],
+	"properties": {
+		"page_id": {"type": "string", "minLength": 1},
+		"user_id": {"type": "string", "minLength": 1},
+		"view_duration_ms": {"type": "integer", "minimum": 1},
+		"region": {"type": "string", "enum": ["EU", "US", "APAC", "LATAM"]},
+		"is_bounce": {"type": "boolean"}
+	}
+}`
+
+func validEvent() PageViewEvent {
+	return PageViewEvent{
+		PageID:       "page",
+		UserID:       "user",
+		ViewDuration: 1000,
+		Timestamp:    time.Now(),
+		Region:       "EU",
+	}
+}
+
+func TestSchemaValidator_ValidEvent(t *testing.T) {
+	v, err := NewSchemaValidator([]byte(pageViewSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := validEvent()
+	if err = v.Validate(&e); err != nil {
+		t.Fatalf("expected valid event, got %v", err)
+	}
+}
+
+func TestSchemaValidator_EmptyPageIDDefect(t *testing.T) {
+	v, err := NewSchemaValidator([]byte(pageViewSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := validEvent()
+	e.PageID = ""
+
+	if err = v.Validate(&e); !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("expected ErrSchemaMismatch, got %v", err)
+	}
+}
+
+func TestSchemaValidator_NegativeDuration(t *testing.T) {
+	v, err := NewSchemaValidator([]byte(pageViewSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := validEvent()
+	e.ViewDuration = -1
+
+	if err = v.Validate(&e); !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("expected ErrSchemaMismatch, got %v", err)
+	}
+}
+
+func TestSchemaValidator_MissingRequired(t *testing.T) {
+	v, err := NewSchemaValidator([]byte(`{"type": "object", "required": ["session_id"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := validEvent()
+	if err = v.Validate(&e); !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("expected ErrSchemaMismatch, got %v", err)
+	}
+}
+
+func TestSchemaValidator_InvalidSchema(t *testing.T) {
+	if _, err := NewSchemaValidator([]byte(`{"type": `)); !errors.Is(err, ErrInvalidSchema) {
+		t.Fatalf("expected ErrInvalidSchema, got %v", err)
+	}
+}
+
+func TestSchemaValidator_UnsupportedKeyword(t *testing.T) {
+	for _, schema := range []string{
+		`{"type": "object", "additionalProperties": false}`,
+		`{"type": "object", "properties": {"page_id": {"type": "string", "pattern": "^p"}}}`,
+		`{"type": "object", "properties": {"ip_address": {"type": "string", "format": "ipv4"}}}`,
+		`{"oneOf": [{"type": "object"}]}`,
+	} {
+		if _, err := NewSchemaValidator([]byte(schema)); !errors.Is(err, ErrInvalidSchema) {
+			t.Fatalf("expected ErrInvalidSchema for %s, got %v", schema, err)
+		}
+	}
+
+	annotated := `{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "PageView", "type": "object"}`
+	if _, err := NewSchemaValidator([]byte(annotated)); err != nil {
+		t.Fatalf("expected annotations to be accepted, got %v", err)
+	}
+}
+
+func TestSchemaValidator_UnionType(t *testing.T) {
+	v, err := NewSchemaValidator([]byte(`{
+		"type": "object",
+		"properties": {"region": {"type": ["string", "null"]}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := validEvent()
+	if err = v.Validate(&e); err != nil {
+		t.Fatalf("expected valid event, got %v", err)
+	}
+
+	v, err = NewSchemaValidator([]byte(`{
+		"type": "object",
+		"properties": {"is_bounce": {"type": ["string", "null"]}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = v.Validate(&e); !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("expected ErrSchemaMismatch for boolean is_bounce, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// TimestampEncoding формат поля timestamp в JSON события.
+type TimestampEncoding int32
+
+const (
+	TimestampRFC3339Nano TimestampEncoding = iota // Строка RFC3339 с наносекундами (по умолчанию)
+	TimestampRFC3339                              // Строка RFC3339 с точностью до секунды
+	TimestampUnixSeconds                          // Число секунд Unix
+	TimestampUnixMillis                           // Число миллисекунд Unix
+)
+
+// timestampEncoding текущий формат timestamp для всех событий
+var timestampEncoding atomic.Int32
+
+// SetTimestampEncoding задает формат timestamp при сериализации событий.
+// Действует на все события пакета, поэтому обычно вызывается при старте.
+func SetTimestampEncoding(enc TimestampEncoding) error {
+	switch enc {
+	case TimestampRFC3339Nano, TimestampRFC3339, TimestampUnixSeconds, TimestampUnixMillis:
+	default:
+		return ErrInvalidTimestampEncoding
+	}
+
+	timestampEncoding.Store(int32(enc))
+	return nil
+}
+
+// CurrentTimestampEncoding возвращает текущий формат timestamp.
+func CurrentTimestampEncoding() TimestampEncoding {
+	return TimestampEncoding(timestampEncoding.Load())
+}
+
+// pageViewEventFields PageViewEvent без методов JSON, чтобы избежать рекурсии
+type pageViewEventFields PageViewEvent
+
+// pageViewEventJSON представление события в JSON, где timestamp
+// кодируется в соответствии с SetTimestampEncoding
+type pageViewEventJSON struct {
+	*pageViewEventFields
+	Timestamp json.RawMessage `json:"timestamp"`
+}
+
+// MarshalJSON сериализует событие, кодируя timestamp в текущем формате.
+func (e PageViewEvent) MarshalJSON() ([]byte, error) {
+	ts, err := encodeTimestamp(e.Timestamp, CurrentTimestampEncoding())
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(pageViewEventJSON{
+		pageViewEventFields: (*pageViewEventFields)(&e),
+		Timestamp:           ts,
+	})
+}
+
+// UnmarshalJSON разбирает событие. Строковый timestamp читается как RFC3339,
+// числовой — как секунды при TimestampUnixSeconds и как миллисекунды иначе.
+func (e *PageViewEvent) UnmarshalJSON(b []byte) error {
+	doc := pageViewEventJSON{pageViewEventFields: (*pageViewEventFields)(e)}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	if len(doc.Timestamp) == 0 {
+		return nil
+	}
+
+	ts, err := decodeTimestamp(doc.Timestamp, CurrentTimestampEncoding())
+	if err != nil {
+		return err
+	}
+
+	e.Timestamp = ts
+	return nil
+}
+
+// encodeTimestamp кодирует время в JSON значение формата enc
+func encodeTimestamp(t time.Time, enc TimestampEncoding) (json.RawMessage, error) {
+	switch enc {
+	case TimestampRFC3339:
+		return json.Marshal(t.Format(time.RFC3339))
+	case TimestampUnixSeconds:
+		return json.Marshal(t.Unix())
+	case TimestampUnixMillis:
+		return json.Marshal(t.UnixMilli())
+	default:
+		return json.Marshal(t)
+	}
+}
+
+// decodeTimestamp разбирает JSON значение timestamp
+func decodeTimestamp(raw json.RawMessage, enc TimestampEncoding) (time.Time, error) {
+	if string(raw) == "null" {
+		return time.Time{}, nil
+	}
+
+	if raw[0] == '"' {
+		var t time.Time
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return time.Time{}, fmt.Errorf("%w: %w", ErrInvalidTimestamp, err)
+		}
+		return t, nil
+	}
+
+	var n int64
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return time.Time{}, fmt.Errorf("%w: %w", ErrInvalidTimestamp, err)
+	}
+
+	if enc == TimestampUnixSeconds {
+		return time.Unix(n, 0), nil
+	}
+	return time.UnixMilli(n), nil
+}
@@ -0,0 +1,17 @@
+package event
+
+import "context"
+
+type tombstoneKey struct{}
+
+// WithTombstone помечает событие, передаваемое с контекстом, как надгробие:
+// отправители записывают для него сообщение с ключом события и пустым значением.
+func WithTombstone(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tombstoneKey{}, true)
+}
+
+// IsTombstone сообщает, помечено ли событие контекста как надгробие WithTombstone.
+func IsTombstone(ctx context.Context) bool {
+	tombstone, _ := ctx.Value(tombstoneKey{}).(bool)
+	return tombstone
+}
@@ -0,0 +1,32 @@
+package event
+
+import "unicode/utf8"
+
+// Validate проверяет событие на известные дефекты данных:
+// пустой page_id, нулевую или отрицательную длительность и некорректные UTF-8 строки.
+func (e *PageViewEvent) Validate() error {
+	if e.PageID == "" {
+		return ErrEmptyPageID
+	}
+
+	if e.ViewDuration < 0 {
+		return ErrNegativeDuration
+	}
+
+	if e.ViewDuration == 0 {
+		return ErrZeroDuration
+	}
+
+	return e.validateUTF8()
+}
+
+// validateUTF8 проверяет, что строковые поля события содержат корректный UTF-8.
+func (e *PageViewEvent) validateUTF8() error {
+	for _, s := range [...]string{e.PageID, e.UserID, e.UserAgent, e.IPAddress, e.Region} {
+		if !utf8.ValidString(s) {
+			return ErrInvalidUTF8
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package generator
+
+import "slices"
+
+// SetEnabledDefects ограничивает дефекты недействительных событий переданным набором.
+// Допускаются встроенные дефекты и идентификаторы, возвращенные AddCustomDefect.
+// Пустой набор допустим только при нулевой вероятности ошибки (SetInvalidRate).
+func (g *EventGenerator) SetEnabledDefects(defectTypes ...int) error {
+	if len(defectTypes) == 0 && g.invalidRate > 0 {
+		return ErrNoDefectsEnabled
+	}
+
+	for _, defectType := range defectTypes {
+		if !g.knownDefect(defectType) {
+			return ErrInvalidDefect
+		}
+	}
+
+	g.enabledDefects = slices.Clone(defectTypes)
+	if g.enabledDefects == nil {
+		g.enabledDefects = []int{}
+	}
+
+	return nil
+}
+
+// AddCustomDefect регистрирует пользовательский дефект: fn искажает
+// корректное событие. Возвращает идентификатор дефекта для SetEnabledDefects
+// и ForceDefect. Если набор дефектов не ограничен, новый дефект сразу участвует в выборе.
+func (g *EventGenerator) AddCustomDefect(fn CustomDefect) int {
+	g.customDefects = append(g.customDefects, fn)
+	return len(defects) + len(g.customDefects) - 1
+}
+
+// knownDefect проверяет, что дефект встроенный или зарегистрирован через AddCustomDefect
+func (g *EventGenerator) knownDefect(defectType int) bool {
+	return slices.Contains(defects[:], defectType) || g.customDefect(defectType) != nil
+}
+
+// customDefect возвращает функцию пользовательского дефекта или nil
+func (g *EventGenerator) customDefect(defectType int) CustomDefect {
+	i := defectType - len(defects)
+	if i < 0 || i >= len(g.customDefects) {
+		return nil
+	}
+
+	return g.customDefects[i]
+}
+
+// hasDefects сообщает, есть ли дефекты для недействительных событий
+func (g *EventGenerator) hasDefects() bool {
+	return g.isDefectForced || g.enabledDefects == nil || len(g.enabledDefects) > 0
+}
+
+// pickDefect выбирает дефект: принудительный, иначе случайный из разрешенных
+func (g *EventGenerator) pickDefect() int {
+	if g.isDefectForced {
+		return g.forcedDefect
+	}
+
+	if g.enabledDefects != nil {
+		return g.enabledDefects[g.rand.Intn(len(g.enabledDefects))]
+	}
+
+	n := g.rand.Intn(len(defects) + len(g.customDefects))
+	if n < len(defects) {
+		return defects[n]
+	}
+
+	return n
+}
@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"math"
+	mrand "math/rand"
+)
+
+// DurationDistribution распределение длительности просмотра.
+// Создается через Uniform или LogNormal.
+type DurationDistribution interface {
+	// sample возвращает длительность из диапазона [min, max]
+	sample(r *mrand.Rand, min, max int) int
+}
+
+// uniformDistribution равномерное распределение, используется по умолчанию
+type uniformDistribution struct{}
+
+// logNormalDistribution логнормальное распределение с параметрами mu и sigma
+// натурального логарифма длительности
+type logNormalDistribution struct {
+	mu, sigma float64
+}
+
+// Uniform возвращает равномерное распределение длительности.
+func Uniform() DurationDistribution {
+	return uniformDistribution{}
+}
+
+// LogNormal возвращает логнормальное распределение длительности:
+// ln(duration) ~ N(mu, sigma²). Значения вне диапазона длительности
+// прижимаются к его границам.
+func LogNormal(mu, sigma float64) DurationDistribution {
+	return logNormalDistribution{mu: mu, sigma: sigma}
+}
+
+func (uniformDistribution) sample(r *mrand.Rand, min, max int) int {
+	return r.Intn(max-min+1) + min
+}
+
+func (d logNormalDistribution) sample(r *mrand.Rand, min, max int) int {
+	v := math.Round(math.Exp(d.mu + d.sigma*r.NormFloat64()))
+	return int(math.Min(math.Max(v, float64(min)), float64(max)))
+}
+
+// SetDurationDistribution задает распределение длительности просмотра
+// в пределах диапазона SetDurationRange. nil возвращает Uniform.
+func (g *EventGenerator) SetDurationDistribution(dist DurationDistribution) {
+	g.durationDist = dist
+}
@@ -0,0 +1,15 @@
+package generator
+
+import "errors"
+
+var (
+	ErrInvalidDefect    = errors.New("invalid defect type")
+	ErrNoDefectsEnabled = errors.New("no defects enabled with non-zero invalid rate")
+
+	ErrInvalidScenarioStep  = errors.New("invalid scenario step")
+	ErrInvalidDurationRange = errors.New("invalid duration range")
+
+	ErrInvalidMode                = errors.New("invalid mode")
+	ErrInvalidProbability         = errors.New("probability must be in [0, 1]")
+	ErrModeProbabilityUnsupported = errors.New("mode has no event probability")
+)
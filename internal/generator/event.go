@@ -8,5 +8,10 @@ type Event struct {
 }
 
 type Meta struct {
-	IsInvalid bool
+	IsInvalid   bool
+	IsDuplicate bool // Точная копия предыдущего события
+	IsTombstone bool // Надгробие для ключа предыдущего события, заполнены только PageID и UserID
+	IsSkewed    bool // Timestamp сдвинут в прошлое или будущее
+
+	ExpectedSerializeError bool // Event.BytesStrict() завершится ошибкой (дефект InvalidJSONDefect)
 }
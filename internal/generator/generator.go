@@ -6,6 +6,8 @@ import (
 	mrand "math/rand"
 	"net"
 	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +17,9 @@ import (
 // Максимальная длительность просмотра по умолчанию (мс)
 const defaultDurationMax = 600
 
+// Минимальная длительность просмотра по умолчанию (мс)
+const defaultDurationMin = 1
+
 // Процент "отскоков" по умолчанию
 const defaultBounceRate = 0.1
 
@@ -26,9 +31,9 @@ const bounceMax = 5_000
 
 // Типы дефектов события
 const (
-	emptyPageIDDefect = iota
-	negativeDurationDefect
-	invalidJSONDefect
+	EmptyPageIDDefect      = iota // Пустой page_id
+	NegativeDurationDefect        // Отрицательная длительность
+	InvalidJSONDefect             // Некорректный JSON
 )
 
 // Частота тикера генерации
@@ -52,38 +57,96 @@ var (
 	// Доступные режимы генерации
 	mods = [...]Mode{RegularMode, PickLoadMode, NightMode}
 	// Дефекты событий
-	defects = [...]int{emptyPageIDDefect, negativeDurationDefect, invalidJSONDefect}
+	defects = [...]int{EmptyPageIDDefect, NegativeDurationDefect, InvalidJSONDefect}
 )
 
 // EventGenerator структура генератора событий
 type EventGenerator struct {
 	durationMax               int                        // Максимальная длительность события
+	durationMin               int                        // Минимальная длительность события
+	durationDist              DurationDistribution       // Распределение длительности события
 	bounceRate                float32                    // Вероятность отскока
 	invalidRate               float32                    // Вероятность преднамеренной ошибки
+	returnVisitorRate         float32                    // Вероятность повторного визита пользователя
+	users                     *userCache                 // Недавние пользователи для повторных визитов
+	stats                     *stats                     // Счетчики сгенерированных событий
 	mode                      Mode                       // Режим генерации
+	modeProbs                 map[Mode]float32           // Переопределенные вероятности события за тик
+	modeProbsMutex            sync.RWMutex               // Защищает modeProbs от гонки с генерацией
 	eventCh                   chan Event                 // Канал для отправки событий
 	stopCh                    chan struct{}              // Канал для остановки генерации
+	closed                    atomic.Bool                // Признак остановки генерации
+	started                   atomic.Bool                // Признак запуска Events или RunScenario
 	postCreateEventsListeners []PostCreateEventsListener // Слушатели события создания событий
+	enrichers                 []Enricher                 // Функции обогащения событий
+	rate                      rateWindow                 // Скользящее окно скорости генерации
+	rateListeners             []RateListener             // Слушатели скорости генерации
+	eventListeners            []EventListener            // Слушатели каждого созданного события
+	forcedDefect              int                        // Принудительно выбранный дефект
+	isDefectForced            bool                       // Признак принудительного выбора дефекта
+	enabledDefects            []int                      // Разрешенные дефекты, nil — все
+	customDefects             []CustomDefect             // Пользовательские дефекты
+	rampUp                    time.Duration              // Длительность плавного разгона генерации
+	schedule                  modeSchedule               // Запланированные переключения режима
+	duplicateRate             float32                    // Вероятность повтора предыдущего события
+	ipv6Rate                  float32                    // Доля событий с IPv6 адресом
+	region                    string                     // Регион всех событий, пустой — случайный
+	tombstoneRate             float32                    // Вероятность надгробия вместо события
+	skewPast, skewFuture      time.Duration              // Границы сдвига Timestamp
+	skewRate                  float32                    // Доля событий со сдвинутым Timestamp
+	idGenerator               func() string              // Генератор идентификаторов PageID/UserID
+	rand                      *mrand.Rand                // Источник случайных решений генератора
+	lastEvent                 atomic.Pointer[Event]      // Последнее сгенерированное событие
+	monotonic                 atomic.Bool                // Признак строго возрастающих Timestamp
+	lastTimestamp             atomic.Int64               // Последний выданный Timestamp (нс)
 }
 
-// NewEventGenerator создает новый экземпляр генератора событий с настройками по умолчанию
-func NewEventGenerator() *EventGenerator {
-	return &EventGenerator{
+// NewEventGenerator создает новый экземпляр генератора событий.
+// Без опций используются настройки по умолчанию.
+func NewEventGenerator(opts ...Option) *EventGenerator {
+	g := &EventGenerator{
 		durationMax: defaultDurationMax,
+		durationMin: defaultDurationMin,
 		bounceRate:  defaultBounceRate,
 		invalidRate: defaultInvalidRate,
 		mode:        defaultMode,
 		eventCh:     make(chan Event),
 		stopCh:      make(chan struct{}),
+		stats:       newStats(),
+		idGenerator: uuid.NewString,
+		rand:        newLockedRand(time.Now().UnixNano()),
+	}
+
+	for _, opt := range opts {
+		opt(g)
 	}
+
+	return g
 }
 
-// SetDurationMax задает максимальную длительность события
+// SetDurationMax задает максимальную длительность события, минимальная сохраняется.
+// Если value меньше минимальной, логирует ErrInvalidDurationRange и не меняет диапазон
 func (g *EventGenerator) SetDurationMax(value int) *EventGenerator {
+	if value < g.durationMin {
+		zap.L().Error(ErrInvalidDurationRange.Error())
+		return g
+	}
 	g.durationMax = value
 	return g
 }
 
+// SetDurationRange задает диапазон длительности событий [min, max].
+// Возвращает ErrInvalidDurationRange, если не выполняется 0 < min <= max.
+func (g *EventGenerator) SetDurationRange(min, max int) error {
+	if min <= 0 || min > max {
+		return ErrInvalidDurationRange
+	}
+
+	g.durationMin = min
+	g.durationMax = max
+	return nil
+}
+
 // SetBounceRate задает вероятность "отскока" для событий
 func (g *EventGenerator) SetBounceRate(value float32) *EventGenerator {
 	g.bounceRate = value
@@ -103,23 +166,135 @@ func (g *EventGenerator) SetInvalidRate(value float32) {
 	g.invalidRate = value
 }
 
+// ForceDefect задает дефект, который будет использоваться для всех
+// недействительных событий до вызова ClearForcedDefect.
+func (g *EventGenerator) ForceDefect(defectType int) {
+	if !g.knownDefect(defectType) {
+		zap.L().Error(ErrInvalidDefect.Error())
+		return
+	}
+	g.forcedDefect = defectType
+	g.isDefectForced = true
+}
+
+// ClearForcedDefect возвращает случайный выбор дефекта.
+func (g *EventGenerator) ClearForcedDefect() {
+	g.isDefectForced = false
+}
+
+// SetDuplicateRate задает вероятность, с которой вместо нового события
+// повторно выдается точная копия предыдущего (Meta.IsDuplicate = true).
+func (g *EventGenerator) SetDuplicateRate(value float32) {
+	g.duplicateRate = value
+}
+
+// SetIDGenerator задает функцию создания идентификаторов PageID и UserID.
+// nil восстанавливает генератор по умолчанию uuid.NewString.
+func (g *EventGenerator) SetIDGenerator(fn func() string) {
+	if fn == nil {
+		fn = uuid.NewString
+	}
+	g.idGenerator = fn
+}
+
+// SetTombstoneRate задает вероятность, с которой вместо нового события выдается
+// надгробие (Meta.IsTombstone = true) для ключа предыдущего события.
+// Pipeline передает надгробия отправителям с контекстом event.WithTombstone,
+// и они записываются с пустым значением. Используется для проверки компактизации топиков.
+func (g *EventGenerator) SetTombstoneRate(value float32) {
+	g.tombstoneRate = value
+}
+
+// SetTimestampSkew задает долю событий rate, у которых Timestamp случайно
+// сдвигается в пределах [-maxPast, maxFuture]. Используется для проверки
+// обработки событий, пришедших не по порядку.
+func (g *EventGenerator) SetTimestampSkew(maxPast, maxFuture time.Duration, rate float32) {
+	g.skewPast = max(maxPast, 0)
+	g.skewFuture = max(maxFuture, 0)
+	g.skewRate = rate
+}
+
+// SetIPv6Rate задает долю событий, получающих случайный IPv6 адрес вместо IPv4
+func (g *EventGenerator) SetIPv6Rate(value float32) {
+	g.ipv6Rate = value
+}
+
+// ScheduleMode планирует переключение на режим mode через after
+// от запуска Events(). Events() проверяет расписание на каждом тике,
+// поэтому несколько вызовов задают последовательность режимов.
+func (g *EventGenerator) ScheduleMode(mode Mode, after time.Duration) {
+	g.schedule.add(after, mode)
+}
+
+// SetEventBuffer задает размер буфера канала событий, чтобы медленный
+// потребитель не блокировал тики генерации, пока буфер не заполнен.
+// Должен вызываться до Events().
+func (g *EventGenerator) SetEventBuffer(n int) {
+	g.eventCh = make(chan Event, max(n, 0))
+}
+
+// SetRampUp задает длительность разгона: после запуска Events() количество событий
+// за тик линейно растет от 0 до нормального для режима значения.
+func (g *EventGenerator) SetRampUp(d time.Duration) {
+	g.rampUp = d
+}
+
+// SetReturnVisitorRate задает вероятность повторного визита: с вероятностью value
+// событие получает UserID одного из cacheSize недавних пользователей, иначе создается новый.
+func (g *EventGenerator) SetReturnVisitorRate(value float32, cacheSize int) {
+	if cacheSize <= 0 {
+		zap.L().Error("invalid return visitor cache size")
+		return
+	}
+	g.returnVisitorRate = value
+	g.users = newUserCache(cacheSize)
+}
+
+// Stats возвращает снимок счетчиков сгенерированных событий
+func (g *EventGenerator) Stats() Stats {
+	return g.stats.snapshot()
+}
+
 // AddPostCreateEventsListener добавляет слушателя, который будет вызван после создания определенного количества событий.
 func (g *EventGenerator) AddPostCreateEventsListener(fn func(count int)) {
 	g.postCreateEventsListeners = append(g.postCreateEventsListeners, fn)
 }
 
+// EventsPerSecond возвращает скорость генерации событий в секунду
+// по скользящему окну последних тиков.
+func (g *EventGenerator) EventsPerSecond() float64 {
+	return g.rate.rate()
+}
+
+// AddRateListener добавляет слушателя, получающего скорость генерации на каждом тике.
+func (g *EventGenerator) AddRateListener(fn RateListener) {
+	g.rateListeners = append(g.rateListeners, fn)
+}
+
+// AddEventListener добавляет слушателя, вызываемого для каждого созданного события.
+// Для дорогих слушателей под нагрузкой используйте обертку Sample.
+func (g *EventGenerator) AddEventListener(fn EventListener) {
+	g.eventListeners = append(g.eventListeners, fn)
+}
+
+// AddEnricher добавляет функцию обогащения, вызываемую для каждого события
+// перед его выдачей. Функции вызываются в порядке регистрации.
+func (g *EventGenerator) AddEnricher(fn Enricher) {
+	g.enrichers = append(g.enrichers, fn)
+}
+
 // eventTick определяет количество событий, генерируемых за тик, в зависимости от режима
 func (g *EventGenerator) eventTick() int {
 	switch g.mode {
 	case RegularMode:
-		if mrand.Float32() < regularModeEventProb {
+		if g.rand.Float32() < 1-g.modeProbability(RegularMode) {
 			return 0
 		}
 		return 1
 	case PickLoadMode:
-		return mrand.Intn(pickLoadMaxEvents-pickLoadMinEvents+1) + pickLoadMinEvents
+		return g.rand.Intn(pickLoadMaxEvents-pickLoadMinEvents+1) + pickLoadMinEvents
 	case NightMode:
-		if mrand.Float32() < nightModeEventProb {
+		if g.rand.Float32() < g.modeProbability(NightMode) {
 			return 1
 		}
 		return 0
@@ -133,58 +308,241 @@ func (g *EventGenerator) eventTick() int {
 func (g *EventGenerator) event() Event {
 	var isBounce, isInvalid bool
 
-	duration := mrand.Intn(g.durationMax) + 1
+	lastEvent := g.lastEvent.Load()
+
+	if lastEvent != nil && g.rand.Float32() < g.tombstoneRate {
+		tombstone := Event{
+			Event: event.PageViewEvent{
+				PageID: lastEvent.Event.PageID,
+				UserID: lastEvent.Event.UserID,
+			},
+			Meta: Meta{IsTombstone: true},
+		}
+		g.stats.add(g.mode, tombstone)
+		g.callEventListeners(tombstone.Event)
+		return tombstone
+	}
+
+	if lastEvent != nil && g.rand.Float32() < g.duplicateRate {
+		duplicate := *lastEvent
+		duplicate.Meta.IsDuplicate = true
+		g.stats.add(g.mode, duplicate)
+		g.callEventListeners(duplicate.Event)
+		return duplicate
+	}
+
+	duration := g.randomDuration()
 
 	if duration < bounceMax {
 		isBounce = false
 	} else {
-		isBounce = mrand.Float32() < g.bounceRate
+		isBounce = g.rand.Float32() < g.bounceRate
 	}
 
-	isInvalid = mrand.Float32() < g.invalidRate
+	isInvalid = g.rand.Float32() < g.invalidRate && g.hasDefects()
 
+	var e Event
 	if isInvalid {
-		return g.getInvalidEvent()
+		e = g.getInvalidEvent()
+	} else {
+		e = g.getValidEvent(duration, isBounce)
 	}
 
-	return g.getValidEvent(duration, isBounce)
+	g.skewTimestamp(&e)
+
+	for _, enrich := range g.enrichers {
+		enrich(&e.Event)
+	}
+
+	g.stats.add(g.mode, e)
+	g.lastEvent.Store(&e)
+	g.callEventListeners(e.Event)
+
+	return e
 }
 
 // Events возвращает канал событий и запускает генерацию в фоне
 func (g *EventGenerator) Events() <-chan Event {
+	g.started.Store(true)
+
 	go func() {
 		ticker := time.NewTicker(tickDuration)
 		defer ticker.Stop()
 
+		start := time.Now()
+
 		for {
 			select {
 			case <-g.stopCh:
 				close(g.eventCh)
 				return
 			case <-ticker.C:
-				eventCount := g.eventTick()
+				if mode, ok := g.schedule.due(time.Since(start)); ok {
+					g.SetMode(mode)
+				}
+
+				eventCount := g.rampedCount(g.eventTick(), time.Since(start))
 
 				for range eventCount {
-					g.eventCh <- g.event()
+					if !g.send(g.event()) {
+						break
+					}
 				}
 
 				g.callPostCreateEventsListeners(eventCount)
+				g.callRateListeners(g.rate.add(eventCount))
 			}
 		}
 	}()
 	return g.eventCh
 }
 
+// send отправляет событие в канал, учитывая в Stats().Blocked
+// время ожидания медленного потребителя.
+// Возвращает false, если генератор остановлен до отправки.
+func (g *EventGenerator) send(e Event) bool {
+	select {
+	case g.eventCh <- e:
+		return true
+	default:
+	}
+
+	start := time.Now()
+	defer func() {
+		g.stats.addBlocked(time.Since(start))
+	}()
+
+	select {
+	case g.eventCh <- e:
+		return true
+	case <-g.stopCh:
+		return false
+	}
+}
+
+// rampedCount масштабирует количество событий тика с учетом разгона.
+// Дробная часть реализуется вероятностно, чтобы разгон работал и для режимов
+// с 0–1 событием за тик.
+func (g *EventGenerator) rampedCount(count int, elapsed time.Duration) int {
+	if g.rampUp <= 0 || elapsed >= g.rampUp {
+		return count
+	}
+
+	scaled := float64(count) * float64(elapsed) / float64(g.rampUp)
+	result := int(scaled)
+	if g.rand.Float64() < scaled-float64(result) {
+		result++
+	}
+
+	return result
+}
+
+// Close останавливает генерацию. Повторный вызов ничего не делает.
 func (g *EventGenerator) Close() {
+	if g.closed.Swap(true) {
+		return
+	}
+
 	close(g.stopCh)
 }
 
+// CloseAndDrain останавливает генерацию и возвращает события,
+// оставшиеся в буфере канала (см. SetEventBuffer) и не прочитанные потребителем.
+func (g *EventGenerator) CloseAndDrain() []Event {
+	g.Close()
+
+	if !g.started.Load() {
+		return nil
+	}
+
+	// канал закрывается циклом генерации после остановки
+	var drained []Event
+	for e := range g.eventCh {
+		drained = append(drained, e)
+	}
+
+	return drained
+}
+
+// userID возвращает UserID для нового события с учетом повторных визитов
+func (g *EventGenerator) userID() string {
+	if g.users == nil {
+		return g.idGenerator()
+	}
+
+	if count := g.users.Len(); count > 0 && g.rand.Float32() < g.returnVisitorRate {
+		if id, ok := g.users.Get(g.rand.Intn(count)); ok {
+			return id
+		}
+	}
+
+	id := g.idGenerator()
+	g.users.Add(id)
+	return id
+}
+
+// SetMonotonicTimestamps включает строго возрастающие Timestamp событий,
+// в том числе при вызове генерации из нескольких горутин: совпадающее
+// или более раннее время сдвигается на 1нс вперед от последнего выданного.
+// Дубликаты сохраняют Timestamp оригинала, SetTimestampSkew сдвигает его после выдачи.
+func (g *EventGenerator) SetMonotonicTimestamps(enabled bool) {
+	g.monotonic.Store(enabled)
+}
+
+// timestamp возвращает время создания события с учетом SetMonotonicTimestamps
+func (g *EventGenerator) timestamp() time.Time {
+	now := time.Now()
+	if !g.monotonic.Load() {
+		return now
+	}
+
+	for {
+		last := g.lastTimestamp.Load()
+		next := max(now.UnixNano(), last+1)
+		if g.lastTimestamp.CompareAndSwap(last, next) {
+			return time.Unix(0, next)
+		}
+	}
+}
+
+// randomDuration возвращает длительность из диапазона [durationMin, durationMax]
+// согласно распределению durationDist, по умолчанию равномерному
+func (g *EventGenerator) randomDuration() int {
+	if g.durationDist == nil {
+		return uniformDistribution{}.sample(g.rand, g.durationMin, g.durationMax)
+	}
+	return g.durationDist.sample(g.rand, g.durationMin, g.durationMax)
+}
+
 func (g *EventGenerator) randomUserAgent() string {
-	return agents[mrand.Intn(len(agents))]
+	return agents[g.rand.Intn(len(agents))]
 }
 
 func (g *EventGenerator) randomRegion() string {
-	return regions[mrand.Intn(len(regions))]
+	if g.region != "" {
+		return g.region
+	}
+	return regions[g.rand.Intn(len(regions))]
+}
+
+// skewTimestamp с вероятностью skewRate сдвигает Timestamp события
+// на случайную величину в пределах [-skewPast, skewFuture]
+func (g *EventGenerator) skewTimestamp(e *Event) {
+	if g.rand.Float32() >= g.skewRate {
+		return
+	}
+
+	offset := time.Duration(g.rand.Int63n(int64(g.skewPast+g.skewFuture)+1)) - g.skewPast
+	e.Event.Timestamp = e.Event.Timestamp.Add(offset)
+	e.Meta.IsSkewed = true
+}
+
+// randomIP возвращает IPv6 адрес с вероятностью ipv6Rate, иначе IPv4
+func (g *EventGenerator) randomIP() string {
+	if g.rand.Float32() < g.ipv6Rate {
+		return g.randomIPv6()
+	}
+	return g.randomIPv4()
 }
 
 func (g *EventGenerator) randomIPv4() string {
@@ -193,54 +551,71 @@ func (g *EventGenerator) randomIPv4() string {
 	return ip.String()
 }
 
+// randomIPv6 генерирует адрес из диапазона global unicast (2000::/3),
+// чтобы он не совпал с IPv4-mapped и другими специальными адресами
+func (g *EventGenerator) randomIPv6() string {
+	ip := make(net.IP, net.IPv6len)
+	_, _ = rand.Read(ip)
+	ip[0] = 0x20 | ip[0]&0x1f
+	return ip.String()
+}
+
 // getInvalidEvent генерирует случайное "недействительное" событие с одним из предопределённых дефектов
 func (g *EventGenerator) getInvalidEvent() Event {
 	var e event.PageViewEvent
 
-	defectType := mrand.Intn(len(defects))
+	defectType := g.pickDefect()
 
 	switch defectType {
-	case emptyPageIDDefect:
+	case EmptyPageIDDefect:
 		e = event.PageViewEvent{
 			PageID:       "",
-			UserID:       uuid.NewString(),
-			ViewDuration: mrand.Intn(g.durationMax) + 1,
-			Timestamp:    time.Now(),
+			UserID:       g.userID(),
+			ViewDuration: g.randomDuration(),
+			Timestamp:    g.timestamp(),
 			UserAgent:    g.randomUserAgent(),
-			IPAddress:    g.randomIPv4(),
+			IPAddress:    g.randomIP(),
 			Region:       g.randomRegion(),
 			IsBounce:     false,
 		}
-	case negativeDurationDefect:
+	case NegativeDurationDefect:
 		e = event.PageViewEvent{
-			PageID:       uuid.NewString(),
-			UserID:       uuid.NewString(),
-			ViewDuration: -(mrand.Intn(g.durationMax) + 1),
-			Timestamp:    time.Now(),
+			PageID:       g.idGenerator(),
+			UserID:       g.userID(),
+			ViewDuration: -g.randomDuration(),
+			Timestamp:    g.timestamp(),
 			UserAgent:    g.randomUserAgent(),
-			IPAddress:    g.randomIPv4(),
+			IPAddress:    g.randomIP(),
 			Region:       g.randomRegion(),
 			IsBounce:     false,
 		}
-	case invalidJSONDefect:
+	case InvalidJSONDefect:
 		e = event.PageViewEvent{
-			PageID:       uuid.NewString(),
-			UserID:       uuid.NewString(),
-			ViewDuration: mrand.Intn(g.durationMax) + 1,
-			Timestamp:    time.Now(),
+			PageID:       g.idGenerator(),
+			UserID:       g.userID(),
+			ViewDuration: g.randomDuration(),
+			Timestamp:    g.timestamp(),
 			UserAgent:    string([]byte{0xff, 0xfe, 0xfd}), // некорректные байты
-			IPAddress:    g.randomIPv4(),
+			IPAddress:    g.randomIP(),
 			Region:       g.randomRegion(),
 			IsBounce:     false,
 		}
 	default:
-		zap.L().Error("invalid defect type")
+		fn := g.customDefect(defectType)
+		if fn == nil {
+			zap.L().Error(ErrInvalidDefect.Error())
+			break
+		}
+
+		e = g.getValidEvent(g.randomDuration(), false).Event
+		fn(&e)
 	}
 
 	return Event{
 		Event: e,
 		Meta: Meta{
-			IsInvalid: true,
+			IsInvalid:              true,
+			ExpectedSerializeError: defectType == InvalidJSONDefect,
 		},
 	}
 }
@@ -249,12 +624,12 @@ func (g *EventGenerator) getInvalidEvent() Event {
 func (g *EventGenerator) getValidEvent(duration int, isBounce bool) Event {
 	return Event{
 		Event: event.PageViewEvent{
-			PageID:       uuid.NewString(),
-			UserID:       uuid.NewString(),
+			PageID:       g.idGenerator(),
+			UserID:       g.userID(),
 			ViewDuration: duration,
-			Timestamp:    time.Now(),
+			Timestamp:    g.timestamp(),
 			UserAgent:    g.randomUserAgent(),
-			IPAddress:    g.randomIPv4(),
+			IPAddress:    g.randomIP(),
 			Region:       g.randomRegion(),
 			IsBounce:     isBounce,
 		},
@@ -271,3 +646,17 @@ func (g *EventGenerator) callPostCreateEventsListeners(count int) {
 		listener(count)
 	}
 }
+
+// callEventListeners вызывает всех слушателей созданного события.
+func (g *EventGenerator) callEventListeners(e event.PageViewEvent) {
+	for _, listener := range g.eventListeners {
+		listener(e)
+	}
+}
+
+// callRateListeners вызывает всех слушателей скорости генерации.
+func (g *EventGenerator) callRateListeners(eps float64) {
+	for _, listener := range g.rateListeners {
+		listener(eps)
+	}
+}
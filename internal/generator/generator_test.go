@@ -1,8 +1,17 @@
 package generator
 
 import (
+	"ay-events-generator/internal/event"
+	"errors"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestViewDurationMaxBound(t *testing.T) {
@@ -108,3 +117,938 @@ func TestInvalidEventRate(t *testing.T) {
 		t.Fatalf("Invalid rate out of expected bounds: got %.4f, expected %.4f ± %.4f", actualRate, expectedRate, tolerance)
 	}
 }
+
+func TestReturnVisitorRate(t *testing.T) {
+	const totalEvents = 10000
+	const expectedRate = 0.3
+	const tolerance = 0.03
+
+	g := NewEventGenerator()
+	g.SetInvalidRate(0)
+	g.SetReturnVisitorRate(expectedRate, 100)
+
+	seen := make(map[string]struct{}, totalEvents)
+	repeatCount := 0
+	for range totalEvents {
+		e := g.event()
+		if _, ok := seen[e.Event.UserID]; ok {
+			repeatCount++
+		}
+		seen[e.Event.UserID] = struct{}{}
+	}
+
+	actualRate := float64(repeatCount) / float64(totalEvents)
+	if actualRate < expectedRate-tolerance || actualRate > expectedRate+tolerance {
+		t.Fatalf("Return visitor rate out of expected bounds: got %.4f, expected %.4f ± %.4f", actualRate, expectedRate, tolerance)
+	}
+}
+
+func TestUserCacheEvictsOldest(t *testing.T) {
+	c := newUserCache(2)
+	c.Add("a")
+	c.Add("b")
+	c.Add("c")
+
+	if c.Len() != 2 {
+		t.Fatalf("expected cache size 2, got %d", c.Len())
+	}
+
+	for i := range c.Len() {
+		if id, _ := c.Get(i); id == "a" {
+			t.Fatal("oldest user was not evicted")
+		}
+	}
+}
+
+func TestStatsReconcile(t *testing.T) {
+	const totalEvents = 1000
+
+	g := NewEventGenerator()
+	g.SetDurationMax(80_000)
+	g.SetBounceRate(0.5)
+
+	var invalid, bounce uint64
+	for i := range totalEvents {
+		if i == totalEvents/2 {
+			g.SetMode(NightMode)
+		}
+		e := g.event()
+		if e.Meta.IsInvalid {
+			invalid++
+		}
+		if e.Event.IsBounce {
+			bounce++
+		}
+	}
+
+	stats := g.Stats()
+	if stats.Total != totalEvents {
+		t.Fatalf("expected total %d, got %d", totalEvents, stats.Total)
+	}
+	if stats.Invalid != invalid {
+		t.Fatalf("expected invalid %d, got %d", invalid, stats.Invalid)
+	}
+	if stats.Bounce != bounce {
+		t.Fatalf("expected bounce %d, got %d", bounce, stats.Bounce)
+	}
+	if stats.ByMode[RegularMode] != totalEvents/2 || stats.ByMode[NightMode] != totalEvents/2 {
+		t.Fatalf("unexpected per-mode counts: %v", stats.ByMode)
+	}
+	if stats.ByMode[PickLoadMode] != 0 {
+		t.Fatalf("expected no PickLoadMode events, got %d", stats.ByMode[PickLoadMode])
+	}
+}
+
+func TestEnrichersRunInOrder(t *testing.T) {
+	g := NewEventGenerator()
+	g.SetInvalidRate(0.5)
+
+	g.AddEnricher(func(e *event.PageViewEvent) {
+		ip := net.ParseIP(e.IPAddress)
+		if ip != nil && ip.To4() != nil && ip.To4()[0] < 128 {
+			e.Region = "EU"
+		} else {
+			e.Region = "US"
+		}
+	})
+	g.AddEnricher(func(e *event.PageViewEvent) {
+		e.Region = "geo:" + e.Region
+	})
+
+	for range 100 {
+		e := g.event()
+
+		expected := "geo:US"
+		if ip := net.ParseIP(e.Event.IPAddress); ip != nil && ip.To4() != nil && ip.To4()[0] < 128 {
+			expected = "geo:EU"
+		}
+
+		if e.Event.Region != expected {
+			t.Fatalf("expected region %q for ip %s, got %q", expected, e.Event.IPAddress, e.Event.Region)
+		}
+	}
+}
+
+func TestEventsPerSecondPickLoadMode(t *testing.T) {
+	g := NewEventGenerator()
+	g.SetMode(PickLoadMode)
+
+	var lastRate atomic.Value
+	g.AddRateListener(func(eps float64) {
+		lastRate.Store(eps)
+	})
+
+	go func() {
+		for range g.Events() {
+		}
+	}()
+
+	time.Sleep(1500 * time.Millisecond)
+	g.Close()
+
+	eps := g.EventsPerSecond()
+	minRate := float64(pickLoadMinEvents) / tickDuration.Seconds()
+	maxRate := float64(pickLoadMaxEvents) / tickDuration.Seconds()
+	if eps < minRate || eps > maxRate {
+		t.Fatalf("PickLoadMode: expected %.0f–%.0f events/sec, got %.2f", minRate, maxRate, eps)
+	}
+
+	if listenerRate, ok := lastRate.Load().(float64); !ok || listenerRate < minRate || listenerRate > maxRate {
+		t.Fatalf("rate listener reported unexpected value %v", lastRate.Load())
+	}
+}
+
+func TestForceDefect(t *testing.T) {
+	g := NewEventGenerator()
+	g.SetInvalidRate(1.0)
+
+	tests := []struct {
+		defect int
+		check  func(e event.PageViewEvent) bool
+	}{
+		{EmptyPageIDDefect, func(e event.PageViewEvent) bool { return e.PageID == "" }},
+		{NegativeDurationDefect, func(e event.PageViewEvent) bool { return e.ViewDuration < 0 }},
+		{InvalidJSONDefect, func(e event.PageViewEvent) bool { return !utf8.ValidString(e.UserAgent) }},
+	}
+
+	for _, tt := range tests {
+		g.ForceDefect(tt.defect)
+
+		for range 100 {
+			e := g.event()
+			if !e.Meta.IsInvalid {
+				t.Fatalf("defect %d: expected invalid event", tt.defect)
+			}
+			if !tt.check(e.Event) {
+				t.Fatalf("defect %d: event does not have the expected malformation: %+v", tt.defect, e.Event)
+			}
+		}
+	}
+
+	g.ClearForcedDefect()
+
+	seen := make(map[bool]int)
+	for range 100 {
+		e := g.event()
+		seen[e.Event.PageID == ""]++
+	}
+	if seen[true] == 0 || seen[false] == 0 {
+		t.Fatal("expected random defects after ClearForcedDefect")
+	}
+}
+
+func TestRampUpThroughput(t *testing.T) {
+	const rampUp = 10 * time.Second
+	const ticks = 1000
+
+	g := NewEventGenerator()
+	g.SetMode(PickLoadMode)
+	g.SetRampUp(rampUp)
+
+	early, steady := 0, 0
+	for range ticks {
+		early += g.rampedCount(g.eventTick(), rampUp/10)
+		steady += g.rampedCount(g.eventTick(), rampUp)
+	}
+
+	if early >= steady/5 {
+		t.Fatalf("expected early-window throughput to be well below steady state: early %d, steady %d", early, steady)
+	}
+
+	g.SetRampUp(0)
+	if got := g.rampedCount(10, 0); got != 10 {
+		t.Fatalf("expected no scaling without ramp-up, got %d", got)
+	}
+}
+
+func TestDuplicateRate(t *testing.T) {
+	const totalEvents = 10000
+	const expectedRate = 0.2
+	const tolerance = 0.02
+
+	g := NewEventGenerator()
+	g.SetDuplicateRate(expectedRate)
+
+	var previous Event
+	duplicateCount := 0
+	for i := range totalEvents {
+		e := g.event()
+		if e.Meta.IsDuplicate {
+			duplicateCount++
+
+			if i == 0 {
+				t.Fatal("first event cannot be a duplicate")
+			}
+			if e.Event.UserID != previous.Event.UserID ||
+				e.Event.PageID != previous.Event.PageID ||
+				!e.Event.Timestamp.Equal(previous.Event.Timestamp) {
+				t.Fatalf("duplicate differs from the previous event: %+v vs %+v", e.Event, previous.Event)
+			}
+		}
+		previous = e
+	}
+
+	actualRate := float64(duplicateCount) / float64(totalEvents)
+	if actualRate < expectedRate-tolerance || actualRate > expectedRate+tolerance {
+		t.Fatalf("Duplicate rate out of expected bounds: got %.4f, expected %.4f ± %.4f", actualRate, expectedRate, tolerance)
+	}
+}
+
+func TestIPv6Rate(t *testing.T) {
+	const totalEvents = 10000
+	const expectedRate = 0.3
+	const tolerance = 0.03
+
+	g := NewEventGenerator()
+	g.SetInvalidRate(0)
+	g.SetIPv6Rate(expectedRate)
+
+	ipv6Count := 0
+	for range totalEvents {
+		e := g.event()
+		ip := net.ParseIP(e.Event.IPAddress)
+		if ip == nil {
+			t.Fatalf("Invalid IP address: %q", e.Event.IPAddress)
+		}
+		if ip.To4() == nil {
+			ipv6Count++
+		}
+	}
+
+	actualRate := float64(ipv6Count) / float64(totalEvents)
+	if actualRate < expectedRate-tolerance || actualRate > expectedRate+tolerance {
+		t.Fatalf("IPv6 rate out of expected bounds: got %.4f, expected %.4f ± %.4f", actualRate, expectedRate, tolerance)
+	}
+}
+
+func TestSampleEventListener(t *testing.T) {
+	const totalEvents = 10000
+	const expectedRate = 0.1
+	const tolerance = 0.02
+
+	g := NewEventGenerator()
+
+	sampledCount := 0
+	estimated := 0.0
+	g.AddEventListener(Sample(expectedRate, func(e event.PageViewEvent) {
+		sampledCount++
+		estimated += SampleWeight(expectedRate)
+	}))
+
+	for range totalEvents {
+		g.event()
+	}
+
+	actualRate := float64(sampledCount) / float64(totalEvents)
+	if actualRate < expectedRate-tolerance || actualRate > expectedRate+tolerance {
+		t.Fatalf("Sample rate out of expected bounds: got %.4f, expected %.4f ± %.4f", actualRate, expectedRate, tolerance)
+	}
+
+	if estimated < totalEvents*(1-tolerance/expectedRate) || estimated > totalEvents*(1+tolerance/expectedRate) {
+		t.Fatalf("Scaled count out of expected bounds: got %.0f, expected ~%d", estimated, totalEvents)
+	}
+}
+
+func TestSetIDGenerator(t *testing.T) {
+	g := NewEventGenerator()
+	g.SetInvalidRate(0)
+
+	var counter atomic.Int64
+	g.SetIDGenerator(func() string {
+		return "id-" + strconv.FormatInt(counter.Add(1), 10)
+	})
+
+	for range 100 {
+		e := g.event().Event
+		if !strings.HasPrefix(e.PageID, "id-") || !strings.HasPrefix(e.UserID, "id-") {
+			t.Fatalf("IDs do not follow injected generator: page=%q user=%q", e.PageID, e.UserID)
+		}
+	}
+
+	g.SetInvalidRate(1)
+	for _, defect := range []int{NegativeDurationDefect, InvalidJSONDefect} {
+		g.ForceDefect(defect)
+		e := g.event().Event
+		if !strings.HasPrefix(e.PageID, "id-") || !strings.HasPrefix(e.UserID, "id-") {
+			t.Fatalf("IDs of invalid event do not follow injected generator: page=%q user=%q", e.PageID, e.UserID)
+		}
+	}
+
+	if counter.Load() == 0 {
+		t.Fatal("injected generator was never called")
+	}
+}
+
+func TestNewEventGeneratorOptions(t *testing.T) {
+	g := NewEventGenerator(
+		WithMode(NightMode),
+		WithInvalidRate(0.5),
+		WithBounceRate(0.2),
+		WithDurationMax(100),
+	)
+
+	if g.mode != NightMode {
+		t.Errorf("expected mode %v, got %v", NightMode, g.mode)
+	}
+	if g.invalidRate != 0.5 {
+		t.Errorf("expected invalid rate 0.5, got %v", g.invalidRate)
+	}
+	if g.bounceRate != 0.2 {
+		t.Errorf("expected bounce rate 0.2, got %v", g.bounceRate)
+	}
+	if g.durationMax != 100 {
+		t.Errorf("expected duration max 100, got %d", g.durationMax)
+	}
+
+	if g := NewEventGenerator(WithMode("unknown")); g.mode != defaultMode {
+		t.Errorf("expected invalid mode to be ignored, got %v", g.mode)
+	}
+}
+
+func TestWithSeedIsReproducible(t *testing.T) {
+	durations := func() []int {
+		g := NewEventGenerator(WithSeed(42), WithInvalidRate(0))
+		result := make([]int, 0, 100)
+		for range 100 {
+			result = append(result, g.event().Event.ViewDuration)
+		}
+		return result
+	}
+
+	first, second := durations(), durations()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("expected equal sequences for the same seed, differ at %d: %d != %d", i, first[i], second[i])
+		}
+	}
+}
+
+func TestTombstoneRate(t *testing.T) {
+	const totalEvents = 10000
+	const expectedRate = 0.2
+	const tolerance = 0.02
+
+	g := NewEventGenerator()
+	g.SetInvalidRate(0)
+	g.SetTombstoneRate(expectedRate)
+
+	tombstoneCount := 0
+	var previous Event
+	for i := range totalEvents {
+		e := g.event()
+		if e.Meta.IsTombstone {
+			tombstoneCount++
+			if i == 0 || e.Event.PartitionKey() != previous.Event.PartitionKey() {
+				t.Fatalf("Tombstone key %q does not match previous event key %q", e.Event.PartitionKey(), previous.Event.PartitionKey())
+			}
+			if e.Event.PageID != previous.Event.PageID || e.Event.ViewDuration != 0 || !e.Event.Timestamp.IsZero() {
+				t.Fatalf("Tombstone must carry only the key fields, got %+v", e.Event)
+			}
+			continue
+		}
+		previous = e
+	}
+
+	actualRate := float64(tombstoneCount) / float64(totalEvents)
+	if actualRate < expectedRate-tolerance || actualRate > expectedRate+tolerance {
+		t.Fatalf("Tombstone rate out of expected bounds: got %.4f, expected %.4f ± %.4f", actualRate, expectedRate, tolerance)
+	}
+
+	if stats := g.Stats(); stats.Tombstones != uint64(tombstoneCount) || stats.Total != totalEvents {
+		t.Fatalf("Stats must count tombstones: got %d of %d, expected %d of %d", stats.Tombstones, stats.Total, tombstoneCount, totalEvents)
+	}
+}
+
+func TestTimestampSkew(t *testing.T) {
+	const totalEvents = 10000
+	const expectedRate = 0.3
+	const tolerance = 0.02
+	const maxPast = time.Hour
+	const maxFuture = 10 * time.Minute
+
+	g := NewEventGenerator()
+	g.SetTimestampSkew(maxPast, maxFuture, expectedRate)
+
+	skewedCount := 0
+	for range totalEvents {
+		before := time.Now()
+		e := g.event()
+		after := time.Now()
+
+		if !e.Meta.IsSkewed {
+			if e.Event.Timestamp.Before(before) || e.Event.Timestamp.After(after) {
+				t.Fatalf("Unskewed timestamp %v outside [%v, %v]", e.Event.Timestamp, before, after)
+			}
+			continue
+		}
+
+		skewedCount++
+		if e.Event.Timestamp.Before(before.Add(-maxPast)) || e.Event.Timestamp.After(after.Add(maxFuture)) {
+			t.Fatalf("Skewed timestamp %v outside bounds [%v, %v]", e.Event.Timestamp, before.Add(-maxPast), after.Add(maxFuture))
+		}
+	}
+
+	actualRate := float64(skewedCount) / float64(totalEvents)
+	if actualRate < expectedRate-tolerance || actualRate > expectedRate+tolerance {
+		t.Fatalf("Skew rate out of expected bounds: got %.4f, expected %.4f ± %.4f", actualRate, expectedRate, tolerance)
+	}
+}
+
+func TestScheduleMode(t *testing.T) {
+	g := NewEventGenerator()
+	g.ScheduleMode(PickLoadMode, time.Minute)
+	g.ScheduleMode(NightMode, 90*time.Second)
+
+	steps := []struct {
+		elapsed time.Duration
+		want    Mode
+	}{
+		{30 * time.Second, RegularMode},
+		{time.Minute, PickLoadMode},
+		{80 * time.Second, PickLoadMode},
+		{90 * time.Second, NightMode},
+		{time.Hour, NightMode},
+	}
+
+	for _, step := range steps {
+		if mode, ok := g.schedule.due(step.elapsed); ok {
+			g.SetMode(mode)
+		}
+		if g.mode != step.want {
+			t.Fatalf("At %v expected mode %q, got %q", step.elapsed, step.want, g.mode)
+		}
+	}
+}
+
+func TestScheduleModeEvents(t *testing.T) {
+	g := NewEventGenerator()
+	g.ScheduleMode(PickLoadMode, 2*tickDuration)
+
+	events := g.Events()
+	defer g.Close()
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case <-events:
+		case <-deadline:
+			t.Fatal("Scheduled mode was not applied by Events()")
+		}
+
+		if g.stats.snapshot().ByMode[PickLoadMode] > 0 {
+			return
+		}
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	g := NewEventGenerator()
+	g.Events()
+
+	g.Close()
+	g.Close()
+}
+
+func TestEventBuffer(t *testing.T) {
+	const buffer = 20
+
+	g := NewEventGenerator(WithMode(PickLoadMode))
+	g.SetEventBuffer(buffer)
+
+	events := g.Events()
+	defer g.Close()
+
+	// потребитель не читает события: генерация продолжается до заполнения буфера
+	deadline := time.After(time.Second)
+	for len(events) < buffer {
+		select {
+		case <-deadline:
+			t.Fatalf("Generation stalled with %d buffered events, expected %d", len(events), buffer)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if total := g.Stats().Total; total < buffer {
+		t.Fatalf("Expected at least %d generated events, got %d", buffer, total)
+	}
+}
+
+func TestExpectedSerializeError(t *testing.T) {
+	g := NewEventGenerator()
+	g.SetInvalidRate(1.0)
+
+	for _, defect := range defects {
+		g.ForceDefect(defect)
+
+		e := g.event()
+		expected := defect == InvalidJSONDefect
+		if e.Meta.ExpectedSerializeError != expected {
+			t.Fatalf("defect %d: expected ExpectedSerializeError=%v", defect, expected)
+		}
+
+		_, err := e.Event.BytesStrict()
+		if expected && !errors.Is(err, event.ErrInvalidUTF8) {
+			t.Fatalf("defect %d: expected ErrInvalidUTF8 from BytesStrict(), got %v", defect, err)
+		}
+		if !expected && err != nil {
+			t.Fatalf("defect %d: unexpected BytesStrict() error: %v", defect, err)
+		}
+
+		// Bytes() по-прежнему сериализует дефектные события
+		if _, err = e.Event.Bytes(); err != nil {
+			t.Fatalf("defect %d: unexpected Bytes() error: %v", defect, err)
+		}
+	}
+}
+
+func TestSetEnabledDefects(t *testing.T) {
+	g := NewEventGenerator()
+	g.SetInvalidRate(1.0)
+
+	if err := g.SetEnabledDefects(NegativeDurationDefect); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 100 {
+		e := g.event()
+		if !e.Meta.IsInvalid || e.Event.ViewDuration >= 0 {
+			t.Fatalf("expected only negative duration defects, got %+v", e.Event)
+		}
+	}
+
+	if err := g.SetEnabledDefects(); !errors.Is(err, ErrNoDefectsEnabled) {
+		t.Fatalf("expected ErrNoDefectsEnabled, got %v", err)
+	}
+	if err := g.SetEnabledDefects(42); !errors.Is(err, ErrInvalidDefect) {
+		t.Fatalf("expected ErrInvalidDefect, got %v", err)
+	}
+
+	g.SetInvalidRate(0)
+	if err := g.SetEnabledDefects(); err != nil {
+		t.Fatalf("empty defect set must be allowed with zero invalid rate, got %v", err)
+	}
+}
+
+func TestAddCustomDefect(t *testing.T) {
+	g := NewEventGenerator()
+	g.SetInvalidRate(1.0)
+
+	defect := g.AddCustomDefect(func(e *event.PageViewEvent) {
+		e.Region = "MARS"
+	})
+
+	if err := g.SetEnabledDefects(defect); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 100 {
+		e := g.event()
+		if !e.Meta.IsInvalid || e.Event.Region != "MARS" {
+			t.Fatalf("expected custom defect, got %+v", e.Event)
+		}
+		if e.Event.PageID == "" || e.Event.ViewDuration <= 0 {
+			t.Fatalf("custom defect must start from a valid event, got %+v", e.Event)
+		}
+	}
+
+	g.ForceDefect(EmptyPageIDDefect)
+	if e := g.event(); e.Event.PageID != "" {
+		t.Fatalf("forced defect must take precedence, got %+v", e.Event)
+	}
+}
+
+func TestBlockedTime(t *testing.T) {
+	g := NewEventGenerator(WithMode(PickLoadMode))
+
+	events := g.Events()
+	defer g.Close()
+
+	// медленный потребитель: генератор ждет на отправке в небуферизованный канал
+	for range 5 {
+		time.Sleep(20 * time.Millisecond)
+		<-events
+	}
+
+	if blocked := g.Stats().Blocked; blocked < 50*time.Millisecond {
+		t.Fatalf("Expected blocked time to advance with a slow consumer, got %v", blocked)
+	}
+}
+
+// TestRunScenario проверяет, что события сценария выдаются по шагам
+// с переопределенными настройками, а канал закрывается после последнего шага
+func TestRunScenario(t *testing.T) {
+	valid, invalid := float32(0), float32(1)
+
+	g := NewEventGenerator(WithSeed(1))
+	events, err := g.RunScenario([]ScenarioStep{
+		{Count: 10, Region: "EU", InvalidRate: &valid},
+		{Count: 5, InvalidRate: &invalid},
+		{Count: 20, Mode: PickLoadMode, InvalidRate: &valid},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+
+	if len(got) != 35 {
+		t.Fatalf("expected 35 events, got %d", len(got))
+	}
+	for i, e := range got {
+		switch {
+		case i < 10:
+			if e.Meta.IsInvalid || e.Event.Region != "EU" {
+				t.Fatalf("event %d: expected valid EU event, got %+v", i, e)
+			}
+		case i < 15:
+			if !e.Meta.IsInvalid {
+				t.Fatalf("event %d: expected invalid event", i)
+			}
+		default:
+			if e.Meta.IsInvalid {
+				t.Fatalf("event %d: expected valid event", i)
+			}
+		}
+	}
+
+	if stats := g.Stats(); stats.ByMode[PickLoadMode] != 20 {
+		t.Fatalf("expected 20 events in pick mode, got %d", stats.ByMode[PickLoadMode])
+	}
+	if g.region != "" || g.invalidRate != defaultInvalidRate || g.mode != defaultMode {
+		t.Fatal("scenario settings were not restored")
+	}
+
+	if _, err := g.RunScenario([]ScenarioStep{{Count: 1, Region: "MARS"}}); !errors.Is(err, ErrInvalidScenarioStep) {
+		t.Fatalf("expected ErrInvalidScenarioStep, got %v", err)
+	}
+}
+
+// TestCloseAndDrain проверяет, что CloseAndDrain возвращает
+// непрочитанные события из буфера в порядке генерации
+func TestCloseAndDrain(t *testing.T) {
+	const buffer = 20
+
+	g := NewEventGenerator(WithMode(PickLoadMode))
+	g.SetEventBuffer(buffer)
+
+	var m sync.Mutex
+	var generated []string
+	g.AddEventListener(func(e event.PageViewEvent) {
+		m.Lock()
+		generated = append(generated, e.PageID)
+		m.Unlock()
+	})
+
+	events := g.Events()
+
+	deadline := time.After(time.Second)
+	for len(events) < buffer {
+		select {
+		case <-deadline:
+			t.Fatalf("Generation stalled with %d buffered events", len(events))
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	var received []string
+	for range 5 {
+		received = append(received, (<-events).Event.PageID)
+	}
+
+	drained := g.CloseAndDrain()
+	if len(drained) < buffer-5 {
+		t.Fatalf("Expected at least %d drained events, got %d", buffer-5, len(drained))
+	}
+	for _, e := range drained {
+		received = append(received, e.Event.PageID)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	// последнее сгенерированное событие могло не попасть в канал из-за остановки
+	if len(generated)-len(received) > 1 || !slices.Equal(received, generated[:len(received)]) {
+		t.Fatalf("Consumed and drained events do not match generated: %d of %d", len(received), len(generated))
+	}
+
+	if _, ok := <-events; ok {
+		t.Fatal("Expected events channel to be closed")
+	}
+}
+
+func TestDurationRange(t *testing.T) {
+	g := NewEventGenerator()
+	g.SetInvalidRate(0)
+
+	if err := g.SetDurationRange(0, 10); !errors.Is(err, ErrInvalidDurationRange) {
+		t.Fatalf("Expected ErrInvalidDurationRange for zero min, got %v", err)
+	}
+	if err := g.SetDurationRange(20, 10); !errors.Is(err, ErrInvalidDurationRange) {
+		t.Fatalf("Expected ErrInvalidDurationRange for min > max, got %v", err)
+	}
+	if err := g.SetDurationRange(100, 200); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[int]struct{})
+	for range 1000 {
+		e := g.event()
+		if d := e.Event.ViewDuration; d < 100 || d > 200 {
+			t.Fatalf("ViewDuration %d out of range [100, 200]", d)
+		}
+		seen[e.Event.ViewDuration] = struct{}{}
+	}
+	if _, ok := seen[100]; !ok {
+		t.Error("Expected lower bound to be reachable")
+	}
+	if _, ok := seen[200]; !ok {
+		t.Error("Expected upper bound to be reachable")
+	}
+
+	// SetDurationMax сохраняет минимальную границу
+	g.SetDurationMax(150)
+	for range 100 {
+		if d := g.event().Event.ViewDuration; d < 100 || d > 150 {
+			t.Fatalf("ViewDuration %d out of range [100, 150]", d)
+		}
+	}
+
+	// максимум меньше минимума отклоняется, диапазон не меняется
+	g.SetDurationMax(5)
+	if g.durationMin != 100 || g.durationMax != 150 {
+		t.Fatalf("Expected range [100, 150] to be kept, got [%d, %d]", g.durationMin, g.durationMax)
+	}
+
+	g = NewEventGenerator(WithDurationMax(0))
+	if g.durationMin != defaultDurationMin || g.durationMax != defaultDurationMax {
+		t.Fatalf("Expected default range for WithDurationMax(0), got [%d, %d]", g.durationMin, g.durationMax)
+	}
+}
+
+func TestDurationDistributionLogNormal(t *testing.T) {
+	g := NewEventGenerator(WithSeed(1), WithInvalidRate(0))
+	if err := g.SetDurationRange(1, 100_000); err != nil {
+		t.Fatal(err)
+	}
+	g.SetDurationDistribution(LogNormal(8, 1))
+
+	const n = 5000
+	durations := make([]int, 0, n)
+	var sum float64
+	for range n {
+		d := g.event().Event.ViewDuration
+		if d < 1 || d > 100_000 {
+			t.Fatalf("ViewDuration %d out of range", d)
+		}
+		durations = append(durations, d)
+		sum += float64(d)
+	}
+
+	slices.Sort(durations)
+	median := float64(durations[n/2])
+	mean := sum / n
+	if median >= mean {
+		t.Fatalf("Expected right-skewed distribution, median %.0f >= mean %.0f", median, mean)
+	}
+
+	// максимальная граница соблюдается и для длинного хвоста
+	g.SetDurationMax(1000)
+	for range 1000 {
+		if d := g.event().Event.ViewDuration; d > 1000 {
+			t.Fatalf("ViewDuration %d exceeds max bound", d)
+		}
+	}
+}
+
+func TestMonotonicTimestamps(t *testing.T) {
+	const (
+		workers = 8
+		perWork = 1000
+	)
+
+	g := NewEventGenerator()
+	g.SetMonotonicTimestamps(true)
+
+	results := make([][]int64, workers)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := range workers {
+		go func() {
+			defer wg.Done()
+			for range perWork {
+				results[w] = append(results[w], g.event().Event.Timestamp.UnixNano())
+			}
+		}()
+	}
+	wg.Wait()
+
+	var all []int64
+	for w, ts := range results {
+		for i := 1; i < len(ts); i++ {
+			if ts[i] <= ts[i-1] {
+				t.Fatalf("Worker %d: timestamp %d is not after %d", w, ts[i], ts[i-1])
+			}
+		}
+		all = append(all, ts...)
+	}
+
+	// в сумме по всем горутинам Timestamp не повторяются
+	slices.Sort(all)
+	for i := 1; i < len(all); i++ {
+		if all[i] == all[i-1] {
+			t.Fatalf("Duplicate timestamp %d", all[i])
+		}
+	}
+}
+
+func TestSetModeProbability(t *testing.T) {
+	const ticks = 10000
+
+	tickRate := func(g *EventGenerator) float64 {
+		events := 0
+		for range ticks {
+			events += g.eventTick()
+		}
+		return float64(events) / ticks
+	}
+
+	g := NewEventGenerator(WithSeed(1))
+	g.SetMode(RegularMode)
+
+	if rate := tickRate(g); rate < 0.88 || rate > 0.92 {
+		t.Fatalf("default RegularMode: expected rate ~0.9, got %f", rate)
+	}
+
+	if err := g.SetModeProbability(RegularMode, 0.2); err != nil {
+		t.Fatalf("SetModeProbability: %v", err)
+	}
+	if prob, err := g.ModeProbability(RegularMode); err != nil || prob != 0.2 {
+		t.Fatalf("ModeProbability: expected 0.2, got %f (%v)", prob, err)
+	}
+	if rate := tickRate(g); rate < 0.18 || rate > 0.22 {
+		t.Fatalf("RegularMode with 0.2: expected rate ~0.2, got %f", rate)
+	}
+
+	if err := g.SetModeProbability(RegularMode, 0); err != nil {
+		t.Fatalf("SetModeProbability: %v", err)
+	}
+	if rate := tickRate(g); rate != 0 {
+		t.Fatalf("RegularMode with 0: expected no events, got rate %f", rate)
+	}
+
+	if prob, err := g.ModeProbability(NightMode); err != nil || prob != nightModeEventProb {
+		t.Fatalf("NightMode: expected default %f, got %f (%v)", nightModeEventProb, prob, err)
+	}
+
+	cases := []struct {
+		mode Mode
+		prob float32
+		err  error
+	}{
+		{RegularMode, 1.5, ErrInvalidProbability},
+		{NightMode, -0.1, ErrInvalidProbability},
+		{PickLoadMode, 0.5, ErrModeProbabilityUnsupported},
+		{Mode("unknown"), 0.5, ErrInvalidMode},
+	}
+	for _, c := range cases {
+		if err := g.SetModeProbability(c.mode, c.prob); !errors.Is(err, c.err) {
+			t.Fatalf("SetModeProbability(%s, %f): expected %v, got %v", c.mode, c.prob, c.err, err)
+		}
+	}
+}
+
+func TestSetModeProbabilityWhileEvents(t *testing.T) {
+	g := NewEventGenerator(WithMode(RegularMode))
+
+	events := g.Events()
+	defer g.Close()
+
+	// изменение вероятности во время генерации не должно вызывать гонку (go test -race)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if err := g.SetModeProbability(RegularMode, 0.9+float32(i%2)/10); err != nil {
+				t.Errorf("SetModeProbability: %v", err)
+				return
+			}
+		}
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for range 3 {
+		select {
+		case <-events:
+		case <-deadline:
+			t.Fatal("Events() stopped producing events")
+		}
+	}
+}
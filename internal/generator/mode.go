@@ -19,3 +19,59 @@ const (
 	pickLoadMaxEvents    = 50
 	nightModeEventProb   = 0.01
 )
+
+// SetModeProbability задает вероятность генерации события за тик для режима.
+// Безопасен для вызова во время работы Events.
+// Поддерживаются RegularMode и NightMode, PickLoadMode генерирует
+// фиксированный диапазон событий и возвращает ErrModeProbabilityUnsupported.
+func (g *EventGenerator) SetModeProbability(mode Mode, prob float32) error {
+	if _, err := g.ModeProbability(mode); err != nil {
+		return err
+	}
+	if prob < 0 || prob > 1 {
+		return ErrInvalidProbability
+	}
+
+	g.modeProbsMutex.Lock()
+	defer g.modeProbsMutex.Unlock()
+
+	if g.modeProbs == nil {
+		g.modeProbs = make(map[Mode]float32, len(mods))
+	}
+	g.modeProbs[mode] = prob
+
+	return nil
+}
+
+// ModeProbability возвращает текущую вероятность генерации события за тик для режима.
+func (g *EventGenerator) ModeProbability(mode Mode) (float32, error) {
+	switch mode {
+	case RegularMode, NightMode:
+		return g.modeProbability(mode), nil
+	case PickLoadMode:
+		return 0, ErrModeProbabilityUnsupported
+	default:
+		return 0, ErrInvalidMode
+	}
+}
+
+// modeProbability возвращает переопределенную или стандартную вероятность режима
+func (g *EventGenerator) modeProbability(mode Mode) float32 {
+	g.modeProbsMutex.RLock()
+	prob, ok := g.modeProbs[mode]
+	g.modeProbsMutex.RUnlock()
+
+	if ok {
+		return prob
+	}
+
+	switch mode {
+	case RegularMode:
+		// regularModeEventProb задает вероятность тика без события
+		return 1 - regularModeEventProb
+	case NightMode:
+		return nightModeEventProb
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// Option настраивает EventGenerator при создании.
+type Option func(*EventGenerator)
+
+// WithMode задает режим генерации событий
+func WithMode(mode Mode) Option {
+	return func(g *EventGenerator) {
+		if !slices.Contains(mods[:], mode) {
+			zap.L().Error("invalid mode")
+			return
+		}
+		g.mode = mode
+	}
+}
+
+// WithInvalidRate задает вероятность преднамеренной ошибки в событии
+func WithInvalidRate(value float32) Option {
+	return func(g *EventGenerator) {
+		g.invalidRate = value
+	}
+}
+
+// WithBounceRate задает вероятность "отскока" для событий
+func WithBounceRate(value float32) Option {
+	return func(g *EventGenerator) {
+		g.bounceRate = value
+	}
+}
+
+// WithDurationMax задает максимальную длительность события, минимальная сохраняется
+func WithDurationMax(value int) Option {
+	return func(g *EventGenerator) {
+		g.SetDurationMax(value)
+	}
+}
+
+// WithSeed фиксирует seed случайных решений генератора (режимы, длительности,
+// дефекты, повторы), делая последовательность событий воспроизводимой.
+// Идентификаторы и IP адреса от seed не зависят.
+func WithSeed(seed int64) Option {
+	return func(g *EventGenerator) {
+		g.rand = newLockedRand(seed)
+	}
+}
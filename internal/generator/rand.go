@@ -0,0 +1,36 @@
+package generator
+
+import (
+	mrand "math/rand"
+	"sync"
+)
+
+// lockedSource потокобезопасный источник случайных чисел,
+// позволяющий использовать один *rand.Rand из нескольких горутин.
+type lockedSource struct {
+	m   sync.Mutex
+	src mrand.Source64
+}
+
+// newLockedRand создает потокобезопасный генератор случайных чисел с заданным seed
+func newLockedRand(seed int64) *mrand.Rand {
+	return mrand.New(&lockedSource{src: mrand.NewSource(seed).(mrand.Source64)})
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.src.Seed(seed)
+}
@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"sync"
+	"time"
+)
+
+// Количество тиков в скользящем окне подсчета скорости генерации
+const rateWindowTicks = 10
+
+// rateWindow кольцевой буфер количества событий за последние тики
+type rateWindow struct {
+	m      sync.Mutex
+	counts [rateWindowTicks]int
+	next   int
+	filled int
+}
+
+// add учитывает количество событий очередного тика и возвращает текущую скорость
+func (w *rateWindow) add(count int) float64 {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.counts[w.next] = count
+	w.next = (w.next + 1) % len(w.counts)
+	if w.filled < len(w.counts) {
+		w.filled++
+	}
+
+	return w.rateLocked()
+}
+
+// rate возвращает количество событий в секунду по заполненной части окна
+func (w *rateWindow) rate() float64 {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	return w.rateLocked()
+}
+
+func (w *rateWindow) rateLocked() float64 {
+	if w.filled == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, count := range w.counts {
+		total += count
+	}
+
+	return float64(total) / (time.Duration(w.filled) * tickDuration).Seconds()
+}
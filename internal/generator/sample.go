@@ -0,0 +1,25 @@
+package generator
+
+import (
+	"ay-events-generator/internal/event"
+	mrand "math/rand"
+)
+
+// Sample оборачивает слушателя событий так, что он вызывается лишь для доли rate событий.
+// Счетчики внутри слушателя следует увеличивать на SampleWeight(rate),
+// чтобы их значения оставались оценкой полного количества событий.
+func Sample(rate float32, fn EventListener) EventListener {
+	return func(e event.PageViewEvent) {
+		if mrand.Float32() < rate {
+			fn(e)
+		}
+	}
+}
+
+// SampleWeight возвращает вес одного сэмплированного события для масштабирования счетчиков.
+func SampleWeight(rate float32) float64 {
+	if rate <= 0 {
+		return 0
+	}
+	return 1 / float64(rate)
+}
@@ -0,0 +1,74 @@
+package generator
+
+import "slices"
+
+// ScenarioStep шаг сценария: Count событий с переопределенными настройками.
+// Пустые поля сохраняют текущие настройки генератора.
+type ScenarioStep struct {
+	Count       int      // Количество событий шага
+	Mode        Mode     // Режим, под которым события учитываются в Stats
+	Region      string   // Регион всех событий шага
+	InvalidRate *float32 // Вероятность преднамеренной ошибки
+}
+
+// RunScenario выдает события шагов по порядку, без пауз между ними,
+// и закрывает канал после последнего шага или Close.
+// Настройки генератора восстанавливаются после каждого шага.
+// Не используется одновременно с Events.
+func (g *EventGenerator) RunScenario(steps []ScenarioStep) (<-chan Event, error) {
+	for _, step := range steps {
+		if step.Count < 0 ||
+			step.Mode != "" && !slices.Contains(mods[:], step.Mode) ||
+			step.Region != "" && !slices.Contains(regions[:], step.Region) {
+			return nil, ErrInvalidScenarioStep
+		}
+	}
+
+	g.started.Store(true)
+
+	go func() {
+		defer close(g.eventCh)
+
+		for _, step := range steps {
+			if !g.runStep(step) {
+				return
+			}
+		}
+	}()
+
+	return g.eventCh, nil
+}
+
+// runStep выдает события шага и возвращает false, если генератор остановлен.
+func (g *EventGenerator) runStep(step ScenarioStep) bool {
+	mode, region, invalidRate := g.mode, g.region, g.invalidRate
+	defer func() {
+		g.mode, g.region, g.invalidRate = mode, region, invalidRate
+	}()
+
+	if step.Mode != "" {
+		g.mode = step.Mode
+	}
+	if step.Region != "" {
+		g.region = step.Region
+	}
+	if step.InvalidRate != nil {
+		g.invalidRate = *step.InvalidRate
+	}
+
+	for range step.Count {
+		select {
+		case <-g.stopCh:
+			return false
+		default:
+		}
+
+		if !g.send(g.event()) {
+			return false
+		}
+	}
+
+	g.callPostCreateEventsListeners(step.Count)
+
+	return true
+}
@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// scheduledMode переключение режима, запланированное на момент at от запуска Events()
+type scheduledMode struct {
+	at   time.Duration
+	mode Mode
+}
+
+// modeSchedule упорядоченная по времени очередь запланированных переключений режима
+type modeSchedule struct {
+	m       sync.Mutex
+	entries []scheduledMode
+}
+
+// add добавляет переключение, сохраняя порядок по времени
+func (s *modeSchedule) add(at time.Duration, mode Mode) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	i, _ := slices.BinarySearchFunc(s.entries, at, func(e scheduledMode, at time.Duration) int {
+		if e.at <= at {
+			return -1
+		}
+		return 1
+	})
+	s.entries = slices.Insert(s.entries, i, scheduledMode{at: at, mode: mode})
+}
+
+// due извлекает переключения, наступившие к моменту elapsed,
+// и возвращает режим последнего из них
+func (s *modeSchedule) due(elapsed time.Duration) (Mode, bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	n := 0
+	for n < len(s.entries) && s.entries[n].at <= elapsed {
+		n++
+	}
+	if n == 0 {
+		return "", false
+	}
+
+	mode := s.entries[n-1].mode
+	s.entries = s.entries[n:]
+	return mode, true
+}
@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats снимок счетчиков сгенерированных событий
+type Stats struct {
+	Total   uint64          // Всего событий
+	Invalid uint64          // События с преднамеренными ошибками
+	Bounce  uint64          // Отскоки
+	ByMode  map[Mode]uint64 // Количество событий по режимам генерации
+	Blocked time.Duration   // Время ожидания отправки в канал событий
+
+	Tombstones uint64 // Надгробия, входят в Total
+}
+
+// stats атомарные счетчики, обновляемые при генерации каждого события
+type stats struct {
+	total   atomic.Uint64
+	invalid atomic.Uint64
+	bounce  atomic.Uint64
+	byMode  map[Mode]*atomic.Uint64
+	blocked atomic.Int64
+
+	tombstones atomic.Uint64
+}
+
+func newStats() *stats {
+	s := &stats{
+		byMode: make(map[Mode]*atomic.Uint64, len(mods)),
+	}
+	for _, mode := range mods {
+		s.byMode[mode] = &atomic.Uint64{}
+	}
+	return s
+}
+
+// add учитывает событие, сгенерированное в режиме mode
+func (s *stats) add(mode Mode, e Event) {
+	s.total.Add(1)
+	if e.Meta.IsInvalid {
+		s.invalid.Add(1)
+	}
+	if e.Event.IsBounce {
+		s.bounce.Add(1)
+	}
+	if e.Meta.IsTombstone {
+		s.tombstones.Add(1)
+	}
+	if counter, ok := s.byMode[mode]; ok {
+		counter.Add(1)
+	}
+}
+
+// addBlocked учитывает время, проведенное в ожидании отправки события
+func (s *stats) addBlocked(d time.Duration) {
+	s.blocked.Add(int64(d))
+}
+
+// snapshot возвращает текущие значения счетчиков
+func (s *stats) snapshot() Stats {
+	result := Stats{
+		Total:   s.total.Load(),
+		Invalid: s.invalid.Load(),
+		Bounce:  s.bounce.Load(),
+		ByMode:  make(map[Mode]uint64, len(s.byMode)),
+		Blocked: time.Duration(s.blocked.Load()),
+	}
+	for mode, counter := range s.byMode {
+		result.ByMode[mode] = counter.Load()
+	}
+	result.Tombstones = s.tombstones.Load()
+	return result
+}
@@ -1,3 +1,14 @@
 package generator
 
+import "ay-events-generator/internal/event"
+
 type PostCreateEventsListener = func(count int)
+
+type Enricher = func(e *event.PageViewEvent)
+
+type RateListener = func(eps float64)
+
+type EventListener = func(e event.PageViewEvent)
+
+// CustomDefect искажает корректное событие, превращая его в недействительное.
+type CustomDefect = func(e *event.PageViewEvent)
@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"container/list"
+	"sync"
+)
+
+// userCache ограниченный LRU-кэш недавно встречавшихся UserID.
+// Используется для имитации повторных визитов пользователей.
+type userCache struct {
+	size  int
+	order *list.List
+	items map[string]*list.Element
+	m     sync.Mutex
+}
+
+func newUserCache(size int) *userCache {
+	return &userCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// Len возвращает количество UserID в кэше.
+func (c *userCache) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.order.Len()
+}
+
+// Get возвращает UserID по позиции index (0 — самый недавний)
+// и помечает его как использованный.
+func (c *userCache) Get(index int) (string, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if index < 0 || index >= c.order.Len() {
+		return "", false
+	}
+
+	el := c.order.Front()
+	for range index {
+		el = el.Next()
+	}
+	c.order.MoveToFront(el)
+
+	return el.Value.(string), true
+}
+
+// Add добавляет UserID в кэш, вытесняя самый давний при переполнении.
+func (c *userCache) Add(userID string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if el, ok := c.items[userID]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[userID] = c.order.PushFront(userID)
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(string))
+	}
+}
@@ -1,7 +1,10 @@
 package generator_metrics
 
 import (
+	"ay-events-generator/internal/dispatcher"
 	"ay-events-generator/internal/generator"
+	"ay-events-generator/internal/partition_pool"
+	"ay-events-generator/internal/publisher"
 	"net/http"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -39,5 +42,110 @@ func (m *Metrics) CollectEventGenerator(gen *generator.EventGenerator) error {
 		eventCount.Add(float64(count))
 	})
 
+	blocked := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "event_generation_blocked_seconds",
+		},
+		func() float64 {
+			return gen.Stats().Blocked.Seconds()
+		},
+	)
+
+	if err := m.registry.Register(blocked); err != nil {
+		zap.L().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (m *Metrics) CollectDispatcher(d *dispatcher.Dispatcher) error {
+	attempts := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "dispatcher_attempts_total",
+		},
+	)
+	exhausted := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "dispatcher_exhausted_total",
+		},
+	)
+
+	if err := m.register(attempts, exhausted); err != nil {
+		zap.L().Error(err.Error())
+		return err
+	}
+
+	d.AddAttemptListener(func(attempt int, err error) {
+		attempts.Inc()
+	})
+	d.AddExhaustedListener(func(err error) {
+		exhausted.Inc()
+	})
+
+	return nil
+}
+
+// register регистрирует все коллекторы либо ни одного:
+// при ошибке уже зарегистрированные коллекторы снимаются с регистрации.
+func (m *Metrics) register(cs ...prometheus.Collector) error {
+	for i, c := range cs {
+		if err := m.registry.Register(c); err != nil {
+			for _, registered := range cs[:i] {
+				m.registry.Unregister(registered)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PublisherStats источник счетчиков Publisher независимо от типа сообщений.
+type PublisherStats interface {
+	Stats() publisher.Stats
+}
+
+func (m *Metrics) CollectPublisher(p PublisherStats) error {
+	dropped := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "publisher_dropped_total",
+		},
+		func() float64 {
+			return float64(p.Stats().Dropped)
+		},
+	)
+
+	if err := m.registry.Register(dropped); err != nil {
+		zap.L().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (m *Metrics) CollectPartitionPool(p *partition_pool.PartitionConnPool) error {
+	reconnects := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "kafka_partition_reconnects_total",
+		},
+		func() float64 {
+			return float64(p.Stats().Reconnects)
+		},
+	)
+	dialErrors := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "kafka_partition_dial_errors_total",
+		},
+		func() float64 {
+			return float64(p.Stats().DialErrors)
+		},
+	)
+
+	if err := m.register(reconnects, dialErrors); err != nil {
+		zap.L().Error(err.Error())
+		return err
+	}
+
 	return nil
 }
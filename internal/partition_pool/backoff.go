@@ -0,0 +1,69 @@
+package partition_pool
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// maxReconnectAttempts количество повторов подключения после первой неудачи
+const maxReconnectAttempts = 8
+
+// SetReconnectBackoff включает повтор неудачного переподключения с паузами,
+// растущими экспоненциально от start до max. Каждая пауза выбирается случайно
+// в пределах [d/2, d], чтобы соединения разных партиций не переподключались
+// к восстанавливающемуся брокеру одновременно. Выполняется не более
+// maxReconnectAttempts повторов, после чего запись возвращает ошибку
+// подключения; ожидание также прерывается отменой контекста записи.
+// Нулевой start отключает повторы.
+func (p *PartitionConnPool) SetReconnectBackoff(start, max time.Duration) error {
+	if start < 0 || start > max {
+		return ErrInvalidBackoff
+	}
+
+	p.backoffStart.Store(int64(start))
+	p.backoffMax.Store(int64(max))
+
+	return nil
+}
+
+// dialWithBackoff открывает соединение, повторяя попытки согласно SetReconnectBackoff.
+func (p *PartitionConnPool) dialWithBackoff(ctx context.Context, partition int) (Conn, error) {
+	conn, err := p.dial(ctx, partition)
+
+	for attempt := 0; err != nil; attempt++ {
+		delay := p.backoff(attempt)
+		if delay <= 0 || attempt >= maxReconnectAttempts {
+			return nil, err
+		}
+
+		timer := p.clock.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(err, ctx.Err())
+		case <-timer.C():
+		}
+
+		conn, err = p.dial(ctx, partition)
+	}
+
+	return conn, nil
+}
+
+// backoff возвращает паузу перед повтором attempt (с нуля) с учетом разброса.
+func (p *PartitionConnPool) backoff(attempt int) time.Duration {
+	start := time.Duration(p.backoffStart.Load())
+	maxDelay := time.Duration(p.backoffMax.Load())
+	if start <= 0 {
+		return 0
+	}
+
+	d := maxDelay
+	if attempt < 63 && start <= maxDelay>>attempt {
+		d = start << attempt
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,11 @@
+package partition_pool
+
+import "errors"
+
+var (
+	ErrInvalidPartition = errors.New("invalid partition")
+	ErrInvalidCount     = errors.New("invalid count")
+
+	ErrInvalidConnsCount = errors.New("invalid connections per partition count")
+	ErrInvalidBackoff    = errors.New("invalid reconnect backoff")
+)
@@ -0,0 +1,14 @@
+package partition_pool
+
+import "ay-events-generator/internal/clock"
+
+// Option настраивает PartitionConnPool при создании.
+type Option func(*PartitionConnPool)
+
+// WithClock задает источник времени для пауз между переподключениями.
+// По умолчанию используются реальные часы.
+func WithClock(clk clock.Clock) Option {
+	return func(p *PartitionConnPool) {
+		p.clock = clk
+	}
+}
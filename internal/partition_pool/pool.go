@@ -0,0 +1,215 @@
+package partition_pool
+
+import (
+	"ay-events-generator/internal/clock"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// PartitionConnPool владеет соединениями с лидерами партиций топика.
+// При смене лидера партиции соединение переоткрывается, а ошибка
+// возвращается вызывающему коду, чтобы запись была повторена (например, Dispatcher).
+type PartitionConnPool struct {
+	dialer Dialer
+	slots  []*slot
+
+	reconnects atomic.Uint64
+	dialErrors atomic.Uint64
+
+	backoffStart atomic.Int64
+	backoffMax   atomic.Int64
+	clock        clock.Clock
+}
+
+// slot соединения одной партиции, запись распределяется между ними по кругу.
+type slot struct {
+	m     sync.RWMutex
+	conns []Conn
+	next  atomic.Uint64
+}
+
+// KafkaDialer возвращает Dialer, открывающий соединения через kafka.DialLeader.
+func KafkaDialer(addr, topic string) Dialer {
+	return func(ctx context.Context, partition int) (Conn, error) {
+		return kafka.DialLeader(ctx, "tcp", addr, topic, partition)
+	}
+}
+
+// NewPartitionConnPool открывает соединения со всеми count партициями.
+// Если хотя бы одно соединение не удалось открыть, уже открытые закрываются.
+func NewPartitionConnPool(ctx context.Context, dialer Dialer, count int, opts ...Option) (*PartitionConnPool, error) {
+	if count <= 0 {
+		return nil, ErrInvalidCount
+	}
+
+	p := &PartitionConnPool{
+		dialer: dialer,
+		slots:  make([]*slot, 0, count),
+		clock:  clock.Real(),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	for partition := range count {
+		conn, err := p.dial(ctx, partition)
+		if err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+		p.slots = append(p.slots, &slot{conns: []Conn{conn}})
+	}
+
+	return p, nil
+}
+
+// Write записывает сообщения в партицию.
+// Если брокер сообщил о смене лидера, соединение партиции переоткрывается
+// и возвращается исходная ошибка, чтобы вызывающий код повторил запись.
+func (p *PartitionConnPool) Write(ctx context.Context, partition int, msgs ...kafka.Message) error {
+	if partition < 0 || partition >= len(p.slots) {
+		return ErrInvalidPartition
+	}
+
+	s := p.slots[partition]
+
+	s.m.RLock()
+	conn := s.conns[(s.next.Add(1)-1)%uint64(len(s.conns))]
+	s.m.RUnlock()
+
+	_, err := conn.WriteMessages(msgs...)
+	if err == nil {
+		return nil
+	}
+
+	zap.L().Error(err.Error())
+
+	if isLeaderChanged(err) {
+		if reconnectErr := p.reconnect(ctx, partition, conn); reconnectErr != nil {
+			return errors.Join(err, reconnectErr)
+		}
+	}
+
+	return err
+}
+
+// Close закрывает все соединения пула.
+func (p *PartitionConnPool) Close() error {
+	var errs []error
+
+	for _, s := range p.slots {
+		s.m.Lock()
+		for _, conn := range s.conns {
+			if err := conn.Close(); err != nil {
+				zap.L().Error(err.Error())
+				errs = append(errs, err)
+			}
+		}
+		s.m.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
+
+// SetConnsPerPartition задает количество соединений с каждой партицией.
+// Запись в партицию распределяется между ее соединениями по кругу,
+// чтобы записи в одну партицию не выстраивались в очередь на одном соединении.
+// Недостающие соединения открываются, лишние закрываются.
+// При ошибке открытия уже открытые соединения остаются в пуле.
+func (p *PartitionConnPool) SetConnsPerPartition(ctx context.Context, n int) error {
+	if n <= 0 {
+		return ErrInvalidConnsCount
+	}
+
+	var errs []error
+
+	for partition, s := range p.slots {
+		s.m.Lock()
+
+		for len(s.conns) < n {
+			conn, err := p.dial(ctx, partition)
+			if err != nil {
+				s.m.Unlock()
+				return err
+			}
+			s.conns = append(s.conns, conn)
+		}
+
+		for _, conn := range s.conns[n:] {
+			if err := conn.Close(); err != nil {
+				zap.L().Error(err.Error())
+				errs = append(errs, err)
+			}
+		}
+		clear(s.conns[n:])
+		s.conns = s.conns[:n]
+
+		s.m.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
+
+// reconnect переоткрывает соединение партиции, если оно все еще в пуле.
+// Подключение выполняется без блокировки слота, чтобы запись через другие
+// соединения партиции, Close и SetConnsPerPartition не ждали паузы переподключения.
+// Если другое обращение уже заменило соединение, новое соединение закрывается.
+func (p *PartitionConnPool) reconnect(ctx context.Context, partition int, failed Conn) error {
+	s := p.slots[partition]
+
+	s.m.RLock()
+	inPool := slices.Contains(s.conns, failed)
+	s.m.RUnlock()
+	if !inPool {
+		return nil
+	}
+
+	conn, err := p.dialWithBackoff(ctx, partition)
+	if err != nil {
+		return err
+	}
+
+	s.m.Lock()
+	i := slices.Index(s.conns, failed)
+	if i < 0 {
+		s.m.Unlock()
+		if err = conn.Close(); err != nil {
+			zap.L().Error(err.Error())
+		}
+		return nil
+	}
+	s.conns[i] = conn
+	s.m.Unlock()
+
+	if err = failed.Close(); err != nil {
+		zap.L().Error(err.Error())
+	}
+	p.reconnects.Add(1)
+
+	return nil
+}
+
+// dial открывает соединение с партицией, учитывая ошибки в Stats().DialErrors.
+func (p *PartitionConnPool) dial(ctx context.Context, partition int) (Conn, error) {
+	conn, err := p.dialer(ctx, partition)
+	if err != nil {
+		zap.L().Error(err.Error())
+		p.dialErrors.Add(1)
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// isLeaderChanged определяет, что ошибка вызвана сменой лидера партиции.
+func isLeaderChanged(err error) bool {
+	return errors.Is(err, kafka.NotLeaderForPartition) ||
+		errors.Is(err, kafka.LeaderNotAvailable)
+}
@@ -0,0 +1,305 @@
+package partition_pool
+
+import (
+	"ay-events-generator/internal/clock"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConn запоминает записанные сообщения и возвращает заданную ошибку.
+type fakeConn struct {
+	mu       sync.Mutex
+	err      error
+	messages []kafka.Message
+	closed   bool
+}
+
+func (c *fakeConn) WriteMessages(msgs ...kafka.Message) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return 0, c.err
+	}
+	c.messages = append(c.messages, msgs...)
+	return len(msgs), nil
+}
+
+func (c *fakeConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	return nil
+}
+
+// fakeDialer выдает соединения из очереди для каждой партиции.
+type fakeDialer struct {
+	mu    sync.Mutex
+	conns map[int][]*fakeConn
+	dials map[int]int
+}
+
+func newFakeDialer() *fakeDialer {
+	return &fakeDialer{
+		conns: map[int][]*fakeConn{},
+		dials: map[int]int{},
+	}
+}
+
+func (d *fakeDialer) push(partition int, conn *fakeConn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.conns[partition] = append(d.conns[partition], conn)
+}
+
+func (d *fakeDialer) Dial(ctx context.Context, partition int) (Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.dials[partition]++
+	if len(d.conns[partition]) == 0 {
+		return nil, errors.New("no connection")
+	}
+
+	conn := d.conns[partition][0]
+	d.conns[partition] = d.conns[partition][1:]
+	return conn, nil
+}
+
+func TestPartitionConnPool_Write(t *testing.T) {
+	d := newFakeDialer()
+	first, second := &fakeConn{}, &fakeConn{}
+	d.push(0, first)
+	d.push(1, second)
+
+	p, err := NewPartitionConnPool(t.Context(), d.Dial, 2)
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Write(t.Context(), 1, kafka.Message{Value: []byte("a")}))
+	assert.Len(t, second.messages, 1)
+	assert.Empty(t, first.messages)
+
+	assert.ErrorIs(t, p.Write(t.Context(), 2, kafka.Message{}), ErrInvalidPartition)
+
+	assert.NoError(t, p.Close())
+	assert.True(t, first.closed)
+	assert.True(t, second.closed)
+}
+
+func TestPartitionConnPool_ReconnectOnLeaderChange(t *testing.T) {
+	d := newFakeDialer()
+	stale := &fakeConn{err: kafka.NotLeaderForPartition}
+	fresh := &fakeConn{}
+	d.push(0, stale)
+	d.push(0, fresh)
+
+	p, err := NewPartitionConnPool(t.Context(), d.Dial, 1)
+	assert.NoError(t, err)
+
+	// первая запись получает ошибку смены лидера и переоткрывает соединение
+	err = p.Write(t.Context(), 0, kafka.Message{Value: []byte("a")})
+	assert.ErrorIs(t, err, kafka.NotLeaderForPartition)
+	assert.True(t, stale.closed)
+	assert.Equal(t, 2, d.dials[0])
+
+	// повторная запись уходит в новое соединение
+	assert.NoError(t, p.Write(t.Context(), 0, kafka.Message{Value: []byte("a")}))
+	assert.Len(t, fresh.messages, 1)
+}
+
+func TestPartitionConnPool_NoReconnectOnOtherErrors(t *testing.T) {
+	d := newFakeDialer()
+	expectedErr := errors.New("write failed")
+	d.push(0, &fakeConn{err: expectedErr})
+
+	p, err := NewPartitionConnPool(t.Context(), d.Dial, 1)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, p.Write(t.Context(), 0, kafka.Message{}), expectedErr)
+	assert.Equal(t, 1, d.dials[0])
+}
+
+func TestNewPartitionConnPool_DialError(t *testing.T) {
+	d := newFakeDialer()
+	opened := &fakeConn{}
+	d.push(0, opened)
+
+	_, err := NewPartitionConnPool(t.Context(), d.Dial, 2)
+	assert.Error(t, err)
+	assert.True(t, opened.closed)
+}
+
+func TestPartitionConnPool_Stats(t *testing.T) {
+	d := newFakeDialer()
+	d.push(0, &fakeConn{err: kafka.LeaderNotAvailable})
+
+	p, err := NewPartitionConnPool(t.Context(), d.Dial, 1)
+	assert.NoError(t, err)
+
+	// переподключение не удалось: в очереди dialer нет соединений
+	assert.Error(t, p.Write(t.Context(), 0, kafka.Message{}))
+	assert.Equal(t, Stats{DialErrors: 1}, p.Stats())
+
+	// следующее переподключение успешно
+	d.push(0, &fakeConn{})
+	assert.Error(t, p.Write(t.Context(), 0, kafka.Message{}))
+	assert.Equal(t, Stats{Reconnects: 1, DialErrors: 1}, p.Stats())
+
+	assert.NoError(t, p.Write(t.Context(), 0, kafka.Message{}))
+}
+
+func TestPartitionConnPool_ConnsPerPartition(t *testing.T) {
+	d := newFakeDialer()
+	conns := []*fakeConn{{}, {}, {}}
+	for _, conn := range conns {
+		d.push(0, conn)
+	}
+
+	p, err := NewPartitionConnPool(t.Context(), d.Dial, 1)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, p.SetConnsPerPartition(t.Context(), 0), ErrInvalidConnsCount)
+	assert.NoError(t, p.SetConnsPerPartition(t.Context(), 3))
+	assert.Equal(t, 3, d.dials[0])
+
+	for range 6 {
+		assert.NoError(t, p.Write(t.Context(), 0, kafka.Message{}))
+	}
+
+	// записи распределяются между соединениями партиции по кругу
+	for _, conn := range conns {
+		assert.Len(t, conn.messages, 2)
+	}
+
+	// уменьшение пула закрывает лишние соединения
+	assert.NoError(t, p.SetConnsPerPartition(t.Context(), 1))
+	assert.False(t, conns[0].closed)
+	assert.True(t, conns[1].closed)
+	assert.True(t, conns[2].closed)
+
+	assert.NoError(t, p.Write(t.Context(), 0, kafka.Message{}))
+	assert.Len(t, conns[0].messages, 3)
+}
+
+func TestPartitionConnPool_ReconnectBackoff(t *testing.T) {
+	const (
+		start    = 100 * time.Millisecond
+		maxDelay = 800 * time.Millisecond
+		failures = 5
+	)
+
+	stale := &fakeConn{err: kafka.NotLeaderForPartition}
+	fresh := &fakeConn{}
+
+	var dials atomic.Int32
+	dialer := func(ctx context.Context, partition int) (Conn, error) {
+		switch n := dials.Add(1); {
+		case n == 1:
+			return stale, nil
+		case n <= failures+1:
+			return nil, errors.New("broker unavailable")
+		default:
+			return fresh, nil
+		}
+	}
+
+	clk := clock.NewFake(time.Unix(0, 0))
+	p, err := NewPartitionConnPool(t.Context(), dialer, 1, WithClock(clk))
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, p.SetReconnectBackoff(time.Second, time.Millisecond), ErrInvalidBackoff)
+	assert.NoError(t, p.SetReconnectBackoff(start, maxDelay))
+
+	// паузы растут экспоненциально в пределах [d/2, d] и ограничены maxDelay
+	for attempt, d := range []time.Duration{start, 2 * start, 4 * start, maxDelay, maxDelay, maxDelay} {
+		for range 100 {
+			delay := p.backoff(attempt)
+			assert.GreaterOrEqual(t, delay, d/2)
+			assert.LessOrEqual(t, delay, d)
+		}
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		written <- p.Write(t.Context(), 0, kafka.Message{})
+	}()
+
+	// каждая неудачная попытка ожидает паузу перед следующей
+	for range failures {
+		clk.BlockUntil(1)
+		clk.Advance(maxDelay)
+	}
+
+	select {
+	case err := <-written:
+		assert.ErrorIs(t, err, kafka.NotLeaderForPartition)
+	case <-time.After(time.Second):
+		t.Fatal("reconnect did not finish")
+	}
+
+	assert.Equal(t, int32(failures+2), dials.Load())
+	assert.Equal(t, Stats{Reconnects: 1, DialErrors: failures}, p.Stats())
+
+	assert.NoError(t, p.Write(t.Context(), 0, kafka.Message{}))
+	assert.Len(t, fresh.messages, 1)
+}
+
+func TestPartitionConnPool_ReconnectDoesNotBlockSlot(t *testing.T) {
+	stale := &fakeConn{err: kafka.NotLeaderForPartition}
+	healthy := &fakeConn{}
+	errUnavailable := errors.New("broker unavailable")
+
+	var dials atomic.Int32
+	dialer := func(ctx context.Context, partition int) (Conn, error) {
+		switch dials.Add(1) {
+		case 1:
+			return stale, nil
+		case 2:
+			return healthy, nil
+		default:
+			return nil, errUnavailable
+		}
+	}
+
+	clk := clock.NewFake(time.Unix(0, 0))
+	p, err := NewPartitionConnPool(t.Context(), dialer, 1, WithClock(clk))
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetConnsPerPartition(t.Context(), 2))
+	assert.NoError(t, p.SetReconnectBackoff(time.Second, time.Second))
+
+	written := make(chan error, 1)
+	go func() {
+		written <- p.Write(t.Context(), 0, kafka.Message{})
+	}()
+
+	// пока переподключение ждет паузу, запись через исправное соединение проходит
+	clk.BlockUntil(1)
+	assert.NoError(t, p.Write(t.Context(), 0, kafka.Message{}))
+	assert.Len(t, healthy.messages, 1)
+
+	// число повторов ограничено, без отмены контекста запись завершается ошибкой
+	for range maxReconnectAttempts {
+		clk.BlockUntil(1)
+		clk.Advance(time.Second)
+	}
+
+	select {
+	case err := <-written:
+		assert.ErrorIs(t, err, kafka.NotLeaderForPartition)
+		assert.ErrorIs(t, err, errUnavailable)
+	case <-time.After(time.Second):
+		t.Fatal("reconnect attempts were not bounded")
+	}
+
+	assert.Equal(t, int32(3+maxReconnectAttempts), dials.Load())
+	assert.NoError(t, p.Close())
+}
@@ -0,0 +1,15 @@
+package partition_pool
+
+// Stats снимок счетчиков соединений пула.
+type Stats struct {
+	Reconnects uint64 // Успешные переоткрытия соединений после смены лидера
+	DialErrors uint64 // Неудачные попытки открыть соединение
+}
+
+// Stats возвращает снимок счетчиков пула.
+func (p *PartitionConnPool) Stats() Stats {
+	return Stats{
+		Reconnects: p.reconnects.Load(),
+		DialErrors: p.dialErrors.Load(),
+	}
+}
@@ -0,0 +1,16 @@
+package partition_pool
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Conn подмножество *kafka.Conn, используемое пулом.
+type Conn interface {
+	WriteMessages(msgs ...kafka.Message) (int, error)
+	Close() error
+}
+
+// Dialer открывает соединение с лидером партиции.
+type Dialer = func(ctx context.Context, partition int) (Conn, error)
@@ -4,5 +4,7 @@ type Config[T any] struct {
 	mode  Mode
 	count int
 	keyFn func(T) string
-	rr    *RRCircle
+	// partitionFn явно выбирает партицию в ручном режиме
+	partitionFn func(T) int
+	rr          *RRCircle
 }
@@ -0,0 +1,47 @@
+package partitioner
+
+// Distribution возвращает, сколько сообщений из выборки попало бы в каждую
+// партицию при текущей конфигурации. Состояние Partitioner не меняется:
+// round-robin продолжает с текущей позиции, не сдвигая круг.
+// В случайном режиме возвращается ожидаемое равномерное распределение,
+// в режиме рассылки каждая партиция получает все сообщения.
+// Сообщения, для которых ручной режим возвращает партицию вне диапазона, не учитываются.
+func (p *Partitioner[T]) Distribution(messages []T) map[int]int {
+	config := p.config.Load().(*Config[T])
+	result := make(map[int]int, config.count)
+
+	switch config.mode {
+	case roundRobinMode:
+		start := config.rr.Peek()
+		for i := range messages {
+			result[(start+i)%config.count]++
+		}
+
+	case keyMode:
+		for _, m := range messages {
+			result[p.hashToRange(config.keyFn(m), config.count)]++
+		}
+
+	case randomMode:
+		for i := range messages {
+			result[i%config.count]++
+		}
+
+	case manualMode:
+		for _, m := range messages {
+			if index := config.partitionFn(m); index >= 0 && index < config.count {
+				result[index]++
+			}
+		}
+
+	case fanOutMode:
+		for index := range config.count {
+			result[index] = len(messages)
+		}
+
+	default:
+		p.logger.Error("invalid mode")
+	}
+
+	return result
+}
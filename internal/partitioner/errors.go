@@ -1,9 +1,29 @@
 package partitioner
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	ErrInvalidKey   = errors.New("invalid key")
-	ErrInvalidCount = errors.New("invalid count")
-	ErrInvalidMode  = errors.New("invalid mode")
+	ErrInvalidKey       = errors.New("invalid key")
+	ErrInvalidCount     = errors.New("invalid count")
+	ErrInvalidMode      = errors.New("invalid mode")
+	ErrInvalidPartition = errors.New("invalid partition")
+
+	ErrInvalidPartitionFn = errors.New("invalid partition function")
 )
+
+// ErrPartitionWrite ошибка записи в конкретную партицию.
+type ErrPartitionWrite struct {
+	Partition int
+	Err       error
+}
+
+func (e *ErrPartitionWrite) Error() string {
+	return fmt.Sprintf("partition %d write: %v", e.Partition, e.Err)
+}
+
+func (e *ErrPartitionWrite) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,113 @@
+package partitioner
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// fanOutResult собирает результаты записи сообщения во все партиции,
+// чтобы вызвать callback сообщения один раз.
+type fanOutResult[T any] struct {
+	ctx      context.Context
+	message  T
+	callback Callback[T]
+
+	m         sync.Mutex
+	reported  []bool
+	remaining int
+	errs      []error
+	written   bool // Все записи запущены
+	failed    bool // Запись хотя бы в одну партицию вернула ошибку
+	fired     bool
+}
+
+func newFanOutResult[T any](ctx context.Context, count int, message T, callback Callback[T]) *fanOutResult[T] {
+	return &fanOutResult[T]{
+		ctx:       ctx,
+		message:   message,
+		callback:  callback,
+		reported:  make([]bool, count),
+		remaining: count,
+	}
+}
+
+// partition возвращает callback записи в партицию index.
+// Повторные вызовы для одной партиции игнорируются.
+func (r *fanOutResult[T]) partition(index int) Callback[T] {
+	return func(ctx context.Context, message T, err error) {
+		r.m.Lock()
+		if r.reported[index] {
+			r.m.Unlock()
+			return
+		}
+		r.reported[index] = true
+		r.remaining--
+		if err != nil {
+			r.errs = append(r.errs, &ErrPartitionWrite{Partition: index, Err: err})
+		}
+		fire := r.ready()
+		r.m.Unlock()
+
+		if fire {
+			r.fire()
+		}
+	}
+}
+
+// finish отмечает, что записи во все партиции запущены.
+// failed сообщает, что хотя бы одна запись вернула ошибку: она возвращается
+// вызывающему коду, который сам сообщит о ней, поэтому callback не вызывается.
+func (r *fanOutResult[T]) finish(failed bool) {
+	r.m.Lock()
+	r.written = true
+	r.failed = failed
+	fire := r.ready()
+	r.m.Unlock()
+
+	if fire {
+		r.fire()
+	}
+}
+
+// ready определяет, пора ли вызвать callback. Вызывается под r.m.
+func (r *fanOutResult[T]) ready() bool {
+	if !r.written || r.failed || r.fired || r.remaining > 0 {
+		return false
+	}
+
+	r.fired = true
+	return true
+}
+
+func (r *fanOutResult[T]) fire() {
+	r.callback(r.ctx, r.message, errors.Join(r.errs...))
+}
+
+// fanOut записывает сообщение во все count партиций.
+// Ошибки записи в отдельные партиции объединяются через errors.Join.
+// callback вызывается один раз, когда все партиции сообщили результат,
+// с объединенной ошибкой партиций; если запись хотя бы в одну партицию
+// сразу вернула ошибку, callback не вызывается, а ошибка возвращается.
+func (p *Partitioner[T]) fanOut(ctx context.Context, count int, message T, callback Callback[T]) error {
+	var result *fanOutResult[T]
+	if callback != nil {
+		result = newFanOutResult(ctx, count, message, callback)
+	}
+
+	errs := make([]error, 0, count)
+	for index := range count {
+		var partitionCallback Callback[T]
+		if result != nil {
+			partitionCallback = result.partition(index)
+		}
+		errs = append(errs, p.writePartition(ctx, index, message, partitionCallback))
+	}
+
+	err := errors.Join(errs...)
+	if result != nil {
+		result.finish(err != nil)
+	}
+
+	return err
+}
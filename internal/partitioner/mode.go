@@ -6,6 +6,8 @@ const (
 	randomMode     Mode = "random"
 	roundRobinMode      = "round_robin"
 	keyMode             = "key"
+	manualMode          = "manual"
+	fanOutMode          = "fan_out"
 
 	defaultMode = roundRobinMode
 )
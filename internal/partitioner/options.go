@@ -0,0 +1,14 @@
+package partitioner
+
+import "go.uber.org/zap"
+
+// Option настраивает Partitioner при создании.
+type Option[T any] func(*Partitioner[T])
+
+// WithLogger задает логгер Partitioner.
+// По умолчанию используется глобальный zap.L().
+func WithLogger[T any](logger *zap.Logger) Option[T] {
+	return func(p *Partitioner[T]) {
+		p.logger = logger
+	}
+}
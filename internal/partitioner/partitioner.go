@@ -4,6 +4,7 @@ import (
 	"context"
 	"hash/fnv"
 	"math/rand"
+	"sync"
 	"sync/atomic"
 
 	"go.uber.org/zap"
@@ -15,13 +16,22 @@ import (
 type Partitioner[T any] struct {
 	writePartitionFn WritePartitionFn[T]
 	config           atomic.Value
+	logger           *zap.Logger
+
+	reconfigureListeners      []ReconfigureListener
+	reconfigureListenersMutex sync.RWMutex
 }
 
 // NewPartitioner создаёт новый Partitioner с конфигурацией по умолчанию.
 // По умолчанию используется одна партиция и стандартный режим распределения.
-func NewPartitioner[T any](writeFn WritePartitionFn[T]) *Partitioner[T] {
+func NewPartitioner[T any](writeFn WritePartitionFn[T], opts ...Option[T]) *Partitioner[T] {
 	p := &Partitioner[T]{
 		writePartitionFn: writeFn,
+		logger:           zap.L(),
+	}
+
+	for _, opt := range opts {
+		opt(p)
 	}
 
 	p.config.Store(&Config[T]{
@@ -41,24 +51,45 @@ func (p *Partitioner[T]) WriteFn(ctx context.Context, message T, callback Callba
 	switch config.mode {
 	case roundRobinMode:
 		index := config.rr.Load()
-		return p.writePartitionFn(ctx, index, message, callback)
+		return p.writePartition(ctx, index, message, callback)
 
 	case keyMode:
 		key := config.keyFn(message)
 		index := p.hashToRange(key, config.count)
-		return p.writePartitionFn(ctx, index, message, callback)
+		return p.writePartition(ctx, index, message, callback)
 
 	case randomMode:
 		index := rand.Intn(config.count)
-		return p.writePartitionFn(ctx, index, message, callback)
+		return p.writePartition(ctx, index, message, callback)
+
+	case manualMode:
+		index := config.partitionFn(message)
+		if index < 0 || index >= config.count {
+			p.logger.Error(ErrInvalidPartition.Error())
+			return ErrInvalidPartition
+		}
+		return p.writePartition(ctx, index, message, callback)
+
+	case fanOutMode:
+		return p.fanOut(ctx, config.count, message, callback)
 
 	default:
-		zap.L().Error("invalid mode")
+		p.logger.Error("invalid mode")
 	}
 
 	return ErrInvalidMode
 }
 
+// writePartition передает сообщение в writePartitionFn,
+// оборачивая ошибку в ErrPartitionWrite с номером партиции.
+func (p *Partitioner[T]) writePartition(ctx context.Context, index int, message T, callback Callback[T]) error {
+	if err := p.writePartitionFn(ctx, index, message, callback); err != nil {
+		return &ErrPartitionWrite{Partition: index, Err: err}
+	}
+
+	return nil
+}
+
 // SetRandomMode переключает Partitioner в случайный режим.
 // Каждое сообщение направляется в случайную партицию
 // в диапазоне [0, count).
@@ -68,7 +99,7 @@ func (p *Partitioner[T]) SetRandomMode(count int) error {
 		return ErrInvalidCount
 	}
 
-	p.config.Store(&Config[T]{
+	p.store(&Config[T]{
 		mode:  randomMode,
 		count: count,
 	})
@@ -76,6 +107,23 @@ func (p *Partitioner[T]) SetRandomMode(count int) error {
 	return nil
 }
 
+// SetFanOutMode переключает Partitioner в режим рассылки:
+// каждое сообщение записывается во все партиции [0, count).
+// Ошибки записи в отдельные партиции объединяются через errors.Join,
+// callback вызывается один раз по результатам всех партиций.
+func (p *Partitioner[T]) SetFanOutMode(count int) error {
+	if count <= 0 {
+		return ErrInvalidCount
+	}
+
+	p.store(&Config[T]{
+		mode:  fanOutMode,
+		count: count,
+	})
+
+	return nil
+}
+
 // SetRoundRobinMode переключает Partitioner в режим round-robin.
 // Партиции выбираются последовательно по кругу.
 // Обновление конфигурации происходит атомарно и потокобезопасно.
@@ -84,7 +132,7 @@ func (p *Partitioner[T]) SetRoundRobinMode(count int) error {
 		return ErrInvalidCount
 	}
 
-	p.config.Store(&Config[T]{
+	p.store(&Config[T]{
 		mode:  roundRobinMode,
 		count: count,
 		rr:    NewRRCircle(count),
@@ -93,6 +141,43 @@ func (p *Partitioner[T]) SetRoundRobinMode(count int) error {
 	return nil
 }
 
+// SetRoundRobinModeWithOffset переключает Partitioner в режим round-robin,
+// начиная с партиции start. Позволяет разным экземплярам продюсера
+// начинать с разных партиций и распределять стартовую нагрузку.
+func (p *Partitioner[T]) SetRoundRobinModeWithOffset(count, start int) error {
+	if count <= 0 {
+		return ErrInvalidCount
+	}
+	if start < 0 || start >= count {
+		return ErrInvalidPartition
+	}
+
+	p.store(&Config[T]{
+		mode:  roundRobinMode,
+		count: count,
+		rr:    NewRRCircleWithOffset(count, start),
+	})
+
+	return nil
+}
+
+// SetRoundRobinModeFrom переключает Partitioner в режим round-robin,
+// продолжая с сохраненной позиции pos (см. Position).
+func (p *Partitioner[T]) SetRoundRobinModeFrom(count, pos int) error {
+	return p.SetRoundRobinModeWithOffset(count, pos)
+}
+
+// Position возвращает текущую позицию round-robin круга.
+// ok равен false, если Partitioner не в режиме round-robin.
+func (p *Partitioner[T]) Position() (pos int, ok bool) {
+	config := p.config.Load().(*Config[T])
+	if config.mode != roundRobinMode {
+		return 0, false
+	}
+
+	return config.rr.Position(), true
+}
+
 // SetKeyMode переключает Partitioner в режим распределения по ключу.
 // Переданная функция keyFn извлекает ключ из сообщения;
 // сообщения с одинаковым ключом всегда попадают в одну и ту же партицию.
@@ -104,7 +189,7 @@ func (p *Partitioner[T]) SetKeyMode(keyFn func(m T) string, count int) error {
 		return ErrInvalidKey
 	}
 
-	p.config.Store(&Config[T]{
+	p.store(&Config[T]{
 		mode:  keyMode,
 		count: count,
 		keyFn: keyFn,
@@ -113,13 +198,58 @@ func (p *Partitioner[T]) SetKeyMode(keyFn func(m T) string, count int) error {
 	return nil
 }
 
+// SetManualMode переключает Partitioner в ручной режим.
+// Функция partitionFn возвращает точный номер партиции для сообщения;
+// номер вне диапазона [0, count) приводит к ErrInvalidPartition при записи.
+func (p *Partitioner[T]) SetManualMode(partitionFn func(m T) int, count int) error {
+	if count <= 0 {
+		return ErrInvalidCount
+	}
+	if partitionFn == nil {
+		return ErrInvalidPartitionFn
+	}
+
+	p.store(&Config[T]{
+		mode:        manualMode,
+		count:       count,
+		partitionFn: partitionFn,
+	})
+
+	return nil
+}
+
+// AddReconfigureListener добавляет слушателя смены конфигурации.
+// Слушатель вызывается при каждой смене режима или количества партиций
+// и получает прежнее и новое количество партиций.
+func (p *Partitioner[T]) AddReconfigureListener(fn ReconfigureListener) {
+	p.reconfigureListenersMutex.Lock()
+	defer p.reconfigureListenersMutex.Unlock()
+
+	p.reconfigureListeners = append(p.reconfigureListeners, fn)
+}
+
+// store атомарно применяет новую конфигурацию и оповещает слушателей.
+func (p *Partitioner[T]) store(config *Config[T]) {
+	old := p.config.Swap(config).(*Config[T])
+
+	// слушатели вызываются вне блокировки, чтобы они могли
+	// безопасно регистрировать новых слушателей
+	p.reconfigureListenersMutex.RLock()
+	listeners := p.reconfigureListeners[:len(p.reconfigureListeners):len(p.reconfigureListeners)]
+	p.reconfigureListenersMutex.RUnlock()
+
+	for _, listener := range listeners {
+		listener(old.count, config.count)
+	}
+}
+
 // hashToRange хэширует строку с помощью FNV-1a
 // и отображает результат в диапазон [0, n).
 func (p *Partitioner[T]) hashToRange(s string, n int) int {
 	h := fnv.New32a()
 	_, err := h.Write([]byte(s))
 	if err != nil {
-		zap.L().Error(err.Error())
+		p.logger.Error(err.Error())
 		return 0
 	}
 	return int(h.Sum32() % uint32(n))
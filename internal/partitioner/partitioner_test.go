@@ -1,7 +1,9 @@
 package partitioner
 
 import (
+	"ay-events-generator/internal/event"
 	"context"
+	"errors"
 	"sync"
 	"testing"
 
@@ -63,6 +65,51 @@ func TestPartitioner_KeyMode_StablePartition(t *testing.T) {
 	}
 }
 
+func TestPartitioner_KeyMode_PartitionKey(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got = make(map[string]map[int]struct{})
+	)
+
+	newPartitioner := func() *Partitioner[event.PageViewEvent] {
+		p := NewPartitioner[event.PageViewEvent](func(ctx context.Context, partition int, message event.PageViewEvent, callback Callback[event.PageViewEvent]) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if got[message.UserID] == nil {
+				got[message.UserID] = make(map[int]struct{})
+			}
+			got[message.UserID][partition] = struct{}{}
+			return nil
+		})
+		assert.NoError(t, p.SetKeyMode(func(m event.PageViewEvent) string {
+			return m.PartitionKey()
+		}, 5))
+		return p
+	}
+
+	// события одного пользователя отличаются остальными полями и перемешаны с чужими;
+	// второй партиционер проверяет, что ключ отображается в партицию детерминированно
+	users := []string{"user-1", "user-2", "user-3", "user-4"}
+	regions := []string{"EU", "US", "APAC"}
+	for _, p := range []*Partitioner[event.PageViewEvent]{newPartitioner(), newPartitioner()} {
+		for i := range 30 {
+			e := event.PageViewEvent{
+				PageID:       "page-" + regions[i%len(regions)],
+				UserID:       users[i%len(users)],
+				ViewDuration: i + 1,
+				Region:       regions[i%len(regions)],
+			}
+			assert.NoError(t, p.WriteFn(context.Background(), e, nil))
+		}
+	}
+
+	assert.Len(t, got, len(users))
+	for user, partitions := range got {
+		assert.Len(t, partitions, 1, "события пользователя %s попали в разные партиции", user)
+	}
+}
+
 func TestPartitioner_RoundRobinMode(t *testing.T) {
 	var (
 		mu  sync.Mutex
@@ -82,6 +129,272 @@ func TestPartitioner_RoundRobinMode(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func TestPartitioner_RoundRobinModeWithOffset(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []int
+	)
+
+	p := NewPartitioner[int](recordingWriter[int](&got, &mu))
+	err := p.SetRoundRobinModeWithOffset(3, 2)
+	assert.NoError(t, err)
+
+	for i := 0; i < 6; i++ {
+		err := p.WriteFn(context.Background(), i, nil)
+		assert.NoError(t, err)
+	}
+
+	want := []int{2, 0, 1, 2, 0, 1}
+	assert.Equal(t, want, got)
+
+	assert.ErrorIs(t, p.SetRoundRobinModeWithOffset(3, 3), ErrInvalidPartition)
+	assert.ErrorIs(t, p.SetRoundRobinModeWithOffset(3, -1), ErrInvalidPartition)
+	assert.ErrorIs(t, p.SetRoundRobinModeWithOffset(0, 0), ErrInvalidCount)
+}
+
+func TestPartitioner_RoundRobinModeFrom(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []int
+	)
+
+	p := NewPartitioner[int](recordingWriter[int](&got, &mu))
+	assert.NoError(t, p.SetRoundRobinMode(3))
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, p.WriteFn(context.Background(), i, nil))
+	}
+
+	pos, ok := p.Position()
+	assert.True(t, ok)
+	assert.Equal(t, 1, pos)
+
+	// перезапуск: новый Partitioner продолжает с сохраненной позиции
+	restored := NewPartitioner[int](recordingWriter[int](&got, &mu))
+	assert.NoError(t, restored.SetRoundRobinModeFrom(3, pos))
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, restored.WriteFn(context.Background(), i, nil))
+	}
+
+	want := []int{0, 1, 2, 0, 1, 2, 0}
+	assert.Equal(t, want, got)
+
+	assert.ErrorIs(t, restored.SetRoundRobinModeFrom(3, 3), ErrInvalidPartition)
+	assert.ErrorIs(t, restored.SetRoundRobinModeFrom(3, -1), ErrInvalidPartition)
+
+	assert.NoError(t, restored.SetRandomMode(3))
+	_, ok = restored.Position()
+	assert.False(t, ok)
+}
+
+func TestPartitioner_WriteErrorCarriesPartition(t *testing.T) {
+	errWrite := errors.New("write failed")
+
+	p := NewPartitioner[int](func(ctx context.Context, partition int, message int, callback Callback[int]) error {
+		if partition == 1 {
+			return errWrite
+		}
+		return nil
+	})
+	assert.NoError(t, p.SetRoundRobinMode(3))
+
+	assert.NoError(t, p.WriteFn(context.Background(), 0, nil))
+
+	err := p.WriteFn(context.Background(), 1, nil)
+	assert.ErrorIs(t, err, errWrite)
+
+	var partitionErr *ErrPartitionWrite
+	if assert.ErrorAs(t, err, &partitionErr) {
+		assert.Equal(t, 1, partitionErr.Partition)
+	}
+}
+
+func TestPartitioner_FanOutMode(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []int
+	)
+
+	p := NewPartitioner[int](recordingWriter[int](&got, &mu))
+	assert.NoError(t, p.SetFanOutMode(3))
+	assert.NoError(t, p.WriteFn(context.Background(), 1, nil))
+	assert.Equal(t, []int{0, 1, 2}, got)
+
+	assert.ErrorIs(t, p.SetFanOutMode(0), ErrInvalidCount)
+}
+
+func TestPartitioner_FanOutModeAggregatesErrors(t *testing.T) {
+	errWrite := errors.New("write failed")
+
+	var calls int
+	p := NewPartitioner[int](func(ctx context.Context, partition int, message int, callback Callback[int]) error {
+		calls++
+		if partition != 1 {
+			return errWrite
+		}
+		return nil
+	})
+	assert.NoError(t, p.SetFanOutMode(3))
+
+	err := p.WriteFn(context.Background(), 1, nil)
+	assert.Equal(t, 3, calls)
+	assert.ErrorIs(t, err, errWrite)
+
+	var partitions []int
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var partitionErr *ErrPartitionWrite
+		if assert.ErrorAs(t, e, &partitionErr) {
+			partitions = append(partitions, partitionErr.Partition)
+		}
+	}
+	assert.Equal(t, []int{0, 2}, partitions)
+}
+
+func TestPartitioner_FanOutModeCallbackOnce(t *testing.T) {
+	errWrite := errors.New("write failed")
+
+	// партиции сообщают результат асинхронно, как батчеры
+	var wg sync.WaitGroup
+	p := NewPartitioner[int](func(ctx context.Context, partition int, message int, callback Callback[int]) error {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if partition == 2 {
+				err = errWrite
+			}
+			callback(ctx, message, err)
+		}()
+		return nil
+	})
+	assert.NoError(t, p.SetFanOutMode(3))
+
+	results := make(chan error, 3)
+	assert.NoError(t, p.WriteFn(context.Background(), 1, func(ctx context.Context, message int, err error) {
+		results <- err
+	}))
+	wg.Wait()
+
+	assert.Len(t, results, 1)
+	err := <-results
+	var partitionErr *ErrPartitionWrite
+	if assert.ErrorAs(t, err, &partitionErr) {
+		assert.Equal(t, 2, partitionErr.Partition)
+	}
+	assert.ErrorIs(t, err, errWrite)
+
+	// при синхронной ошибке о ней сообщает вызывающий код, callback не вызывается
+	p = NewPartitioner[int](func(ctx context.Context, partition int, message int, callback Callback[int]) error {
+		if partition == 0 {
+			return errWrite
+		}
+		callback(ctx, message, nil)
+		return nil
+	})
+	assert.NoError(t, p.SetFanOutMode(3))
+
+	var calls int
+	assert.ErrorIs(t, p.WriteFn(context.Background(), 1, func(ctx context.Context, message int, err error) {
+		calls++
+	}), errWrite)
+	assert.Zero(t, calls)
+}
+
+func TestRRCircle_Peek(t *testing.T) {
+	c := NewRRCircle(3)
+
+	assert.Equal(t, 0, c.Peek())
+	assert.Equal(t, 0, c.Peek(), "Peek не должен сдвигать круг")
+	assert.Equal(t, 0, c.Current())
+
+	assert.Equal(t, 0, c.Load())
+	assert.Equal(t, 1, c.Peek())
+	assert.Equal(t, 1, c.Load())
+	assert.Equal(t, 2, c.Load())
+	assert.Equal(t, 0, c.Peek())
+}
+
+func TestPartitioner_ManualMode(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []int
+	)
+
+	p := NewPartitioner[int](recordingWriter[int](&got, &mu))
+	err := p.SetManualMode(func(m int) int { return m }, 3)
+	assert.NoError(t, err)
+
+	for _, m := range []int{2, 0, 1, 2} {
+		err := p.WriteFn(context.Background(), m, nil)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []int{2, 0, 1, 2}, got)
+}
+
+func TestPartitioner_ManualMode_OutOfRange(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []int
+	)
+
+	p := NewPartitioner[int](recordingWriter[int](&got, &mu))
+	err := p.SetManualMode(func(m int) int { return m }, 3)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, p.WriteFn(context.Background(), 3, nil), ErrInvalidPartition)
+	assert.ErrorIs(t, p.WriteFn(context.Background(), -1, nil), ErrInvalidPartition)
+	assert.Empty(t, got, "Сообщения вне диапазона не должны записываться")
+
+	assert.ErrorIs(t, p.SetManualMode(nil, 3), ErrInvalidPartitionFn)
+	assert.ErrorIs(t, p.SetManualMode(func(int) int { return 0 }, 0), ErrInvalidCount)
+}
+
+func TestPartitioner_ReconfigureListener(t *testing.T) {
+	p := NewPartitioner[int](func(ctx context.Context, partition int, message int, callback Callback[int]) error { return nil })
+
+	var transitions [][2]int
+	p.AddReconfigureListener(func(oldCount, newCount int) {
+		transitions = append(transitions, [2]int{oldCount, newCount})
+	})
+
+	assert.NoError(t, p.SetRoundRobinMode(3))
+	assert.NoError(t, p.SetRandomMode(5))
+	assert.NoError(t, p.SetKeyMode(func(m int) string { return "x" }, 2))
+	assert.Error(t, p.SetRoundRobinMode(0), "Некорректная конфигурация не применяется")
+
+	want := [][2]int{{1, 3}, {3, 5}, {5, 2}}
+	assert.Equal(t, want, transitions)
+}
+
+func TestPartitioner_ReconfigureListenerConcurrentAdd(t *testing.T) {
+	p := NewPartitioner[int](func(ctx context.Context, partition int, message int, callback Callback[int]) error { return nil })
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			p.AddReconfigureListener(func(oldCount, newCount int) {})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 100; i++ {
+			assert.NoError(t, p.SetRoundRobinMode(i))
+		}
+	}()
+	wg.Wait()
+
+	var calls int
+	p.AddReconfigureListener(func(oldCount, newCount int) {
+		calls++
+	})
+	assert.NoError(t, p.SetRandomMode(2))
+	assert.Equal(t, 1, calls)
+}
+
 func TestPartitioner_InvalidArgs(t *testing.T) {
 	p := NewPartitioner[int](func(ctx context.Context, partition int, message int, callback Callback[int]) error { return nil })
 
@@ -90,3 +403,86 @@ func TestPartitioner_InvalidArgs(t *testing.T) {
 	assert.Error(t, p.SetKeyMode(nil, 3), "Ожидалась ошибка для nil keyFn")
 	assert.Error(t, p.SetKeyMode(func(int) string { return "x" }, 0), "Ожидалась ошибка для count <= 0")
 }
+
+func TestSequencer_IncrementsPerPartition(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got = map[int][]uint64{}
+	)
+
+	seq, err := NewSequencer(3)
+	assert.NoError(t, err)
+
+	write := WithSequence[int](seq, func(ctx context.Context, partition int, message int, callback Callback[int]) error {
+		n, ok := SequenceFromContext(ctx)
+		assert.True(t, ok)
+		mu.Lock()
+		got[partition] = append(got[partition], n)
+		mu.Unlock()
+		return nil
+	})
+
+	p := NewPartitioner[int](write)
+	assert.NoError(t, p.SetRoundRobinMode(3))
+
+	for i := 0; i < 9; i++ {
+		assert.NoError(t, p.WriteFn(context.Background(), i, nil))
+	}
+
+	for partition := 0; partition < 3; partition++ {
+		assert.Equal(t, []uint64{1, 2, 3}, got[partition])
+		assert.Equal(t, uint64(3), seq.Last(partition))
+	}
+
+	seq.Reset()
+	assert.NoError(t, p.WriteFn(context.Background(), 0, nil))
+	assert.Equal(t, []uint64{1, 2, 3, 1}, got[0])
+
+	err = write(context.Background(), 3, 0, nil)
+	assert.ErrorIs(t, err, ErrInvalidPartition)
+}
+
+func TestPartitioner_Distribution_KeyMode(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []int
+		cnt = 4
+	)
+
+	p := NewPartitioner[string](recordingWriter[string](&got, &mu))
+	err := p.SetKeyMode(func(m string) string { return m }, cnt)
+	assert.NoError(t, err)
+
+	messages := []string{"a", "b", "c", "a", "a", "d", "b", "e"}
+	dist := p.Distribution(messages)
+
+	sum := 0
+	for _, n := range dist {
+		sum += n
+	}
+	assert.Equal(t, len(messages), sum)
+
+	// распределение совпадает с фактическими записями
+	expected := map[int]int{}
+	for _, m := range messages {
+		assert.NoError(t, p.WriteFn(context.Background(), m, nil))
+	}
+	for _, partition := range got {
+		expected[partition]++
+	}
+	assert.Equal(t, expected, dist)
+	assert.GreaterOrEqual(t, dist[p.hashToRange("a", cnt)], 3)
+}
+
+func TestPartitioner_Distribution_RoundRobinDoesNotAdvance(t *testing.T) {
+	p := NewPartitioner[int](func(ctx context.Context, partition int, message int, callback Callback[int]) error {
+		return nil
+	})
+	assert.NoError(t, p.SetRoundRobinModeWithOffset(3, 2))
+
+	assert.Equal(t, map[int]int{2: 2, 0: 2, 1: 1}, p.Distribution(make([]int, 5)))
+
+	pos, ok := p.Position()
+	assert.True(t, ok)
+	assert.Equal(t, 2, pos)
+}
@@ -12,6 +12,11 @@ func NewRRCircle(count int) *RRCircle {
 	return &RRCircle{count: count}
 }
 
+// NewRRCircleWithOffset создает круг, начинающийся с партиции start.
+func NewRRCircleWithOffset(count, start int) *RRCircle {
+	return &RRCircle{v: start, count: count}
+}
+
 func (c *RRCircle) Load() int {
 	c.m.Lock()
 	defer c.m.Unlock()
@@ -26,3 +31,22 @@ func (c *RRCircle) Load() int {
 
 	return v
 }
+
+// Peek возвращает следующую партицию, не сдвигая круг.
+func (c *RRCircle) Peek() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.v
+}
+
+// Current синоним Peek.
+func (c *RRCircle) Current() int {
+	return c.Peek()
+}
+
+// Position возвращает позицию круга для сохранения между перезапусками.
+// Восстанавливается через SetRoundRobinModeFrom.
+func (c *RRCircle) Position() int {
+	return c.Peek()
+}
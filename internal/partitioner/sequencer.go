@@ -0,0 +1,82 @@
+package partitioner
+
+import (
+	"context"
+	"sync"
+)
+
+// SequenceHeader имя заголовка Kafka-сообщения с порядковым номером в партиции.
+const SequenceHeader = "seq"
+
+type sequenceKey struct{}
+
+// Sequencer выдает монотонно возрастающие порядковые номера сообщений
+// отдельно для каждой партиции. Нумерация начинается с 1.
+// По номерам потребитель может обнаружить потери и перестановки сообщений.
+type Sequencer struct {
+	partitions []sequencePartition
+}
+
+// sequencePartition счетчик одной партиции.
+// Мьютекс удерживается на время записи, чтобы порядок номеров
+// совпадал с порядком передачи сообщений в партицию.
+type sequencePartition struct {
+	m    sync.Mutex
+	last uint64
+}
+
+// NewSequencer создает Sequencer для count партиций.
+func NewSequencer(count int) (*Sequencer, error) {
+	if count <= 0 {
+		return nil, ErrInvalidCount
+	}
+
+	return &Sequencer{
+		partitions: make([]sequencePartition, count),
+	}, nil
+}
+
+// Last возвращает последний выданный номер партиции.
+func (s *Sequencer) Last(partition int) uint64 {
+	p := &s.partitions[partition]
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.last
+}
+
+// Reset сбрасывает счетчики всех партиций, следующий номер снова будет 1.
+func (s *Sequencer) Reset() {
+	for i := range s.partitions {
+		p := &s.partitions[i]
+		p.m.Lock()
+		p.last = 0
+		p.m.Unlock()
+	}
+}
+
+// WithSequence оборачивает write так, что каждое сообщение получает следующий
+// номер своей партиции. Номер передается в контексте и читается SequenceFromContext.
+func WithSequence[T any](s *Sequencer, write WritePartitionFn[T]) WritePartitionFn[T] {
+	return func(ctx context.Context, partition int, message T, callback Callback[T]) error {
+		if partition < 0 || partition >= len(s.partitions) {
+			return ErrInvalidPartition
+		}
+
+		p := &s.partitions[partition]
+
+		p.m.Lock()
+		defer p.m.Unlock()
+
+		p.last++
+
+		return write(context.WithValue(ctx, sequenceKey{}, p.last), partition, message, callback)
+	}
+}
+
+// SequenceFromContext возвращает порядковый номер сообщения, назначенный WithSequence.
+func SequenceFromContext(ctx context.Context) (uint64, bool) {
+	seq, ok := ctx.Value(sequenceKey{}).(uint64)
+	return seq, ok
+}
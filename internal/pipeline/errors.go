@@ -0,0 +1,7 @@
+package pipeline
+
+import "errors"
+
+var (
+	ErrClosed = errors.New("pipeline closed")
+)
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/publisher"
+	"io"
+
+	"go.uber.org/zap"
+)
+
+// Option настраивает Pipeline при создании.
+type Option func(*Pipeline)
+
+// WithFlushers задает буферы между Publisher и соединениями.
+// Они закрываются после Publisher в порядке передачи.
+func WithFlushers(flushers ...Flusher) Option {
+	return func(p *Pipeline) {
+		p.flushers = append(p.flushers, flushers...)
+	}
+}
+
+// WithClosers задает ресурсы нижнего уровня (соединения, пулы),
+// закрываемые последними в порядке передачи.
+func WithClosers(closers ...io.Closer) Option {
+	return func(p *Pipeline) {
+		p.closers = append(p.closers, closers...)
+	}
+}
+
+// WithCallback задает callback результата записи каждого события.
+func WithCallback(callback publisher.AsyncCallback[event.PageViewEvent]) Option {
+	return func(p *Pipeline) {
+		p.callback = callback
+	}
+}
+
+// WithLogger задает логгер Pipeline.
+// По умолчанию используется глобальный zap.L().
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Pipeline) {
+		p.logger = logger
+	}
+}
@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/publisher"
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// Pipeline владеет компонентами конвейера событий и завершает их
+// в порядке зависимостей: источник, Publisher, буферы, соединения.
+// Так события, уже выданные источником, доходят до соединений до их закрытия.
+type Pipeline struct {
+	source    Source
+	publisher Publisher
+	flushers  []Flusher
+	closers   []io.Closer
+	callback  publisher.AsyncCallback[event.PageViewEvent]
+	logger    *zap.Logger
+
+	started atomic.Bool
+	closed  atomic.Bool
+	done    chan struct{}
+}
+
+// NewPipeline создает конвейер из источника source и publisher.
+func NewPipeline(source Source, publisher Publisher, opts ...Option) *Pipeline {
+	p := &Pipeline{
+		source:    source,
+		publisher: publisher,
+		logger:    zap.L(),
+		done:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Run передает события источника в Publisher, пока источник не закроет канал
+// (после Shutdown). ctx передается в SendAsync каждого события и не прерывает
+// цикл, чтобы события, выданные до Shutdown, не терялись.
+// Надгробия (Meta.IsTombstone) передаются с контекстом event.WithTombstone.
+// Повторный вызов или вызов после Shutdown возвращает ErrClosed.
+func (p *Pipeline) Run(ctx context.Context) error {
+	if p.started.Swap(true) {
+		return ErrClosed
+	}
+	defer close(p.done)
+
+	for ev := range p.source.Events() {
+		evCtx := ctx
+		if ev.Meta.IsTombstone {
+			evCtx = event.WithTombstone(ctx)
+		}
+
+		if err := p.publisher.SendAsync(evCtx, ev.Event, p.callback); err != nil {
+			p.logger.Error(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Shutdown останавливает источник, дожидается передачи его последних событий
+// в Publisher, затем закрывает Publisher, буферы и соединения.
+// Если ctx истекает раньше завершения Run, завершение продолжается,
+// а ошибка контекста входит в результат. Ошибки закрытия объединяются errors.Join.
+// Повторный вызов возвращает ErrClosed.
+func (p *Pipeline) Shutdown(ctx context.Context) error {
+	if p.closed.Swap(true) {
+		return ErrClosed
+	}
+
+	var errs []error
+
+	// Run, не начавший работу, больше не запустится
+	if !p.started.Swap(true) {
+		close(p.done)
+	}
+
+	p.source.Close()
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		p.logger.Error(ctx.Err().Error())
+		errs = append(errs, ctx.Err())
+	}
+
+	if err := p.publisher.Close(); err != nil {
+		p.logger.Error(err.Error())
+		errs = append(errs, err)
+	}
+
+	for _, f := range p.flushers {
+		f.Close()
+	}
+
+	for _, c := range p.closers {
+		if err := c.Close(); err != nil {
+			p.logger.Error(err.Error())
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
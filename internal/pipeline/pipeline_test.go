@@ -0,0 +1,257 @@
+package pipeline
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/generator"
+	"ay-events-generator/internal/publisher"
+	"ay-events-generator/internal/sender"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// closeLog запоминает порядок закрытия компонентов.
+type closeLog struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (l *closeLog) add(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order = append(l.order, name)
+}
+
+func (l *closeLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.order...)
+}
+
+// fakeSource выдает заранее заданные события, канал закрывается в Close.
+type fakeSource struct {
+	log    *closeLog
+	events chan generator.Event
+}
+
+func newFakeSource(log *closeLog, count int) *fakeSource {
+	s := &fakeSource{log: log, events: make(chan generator.Event, count)}
+	for i := range count {
+		s.events <- generator.Event{Event: event.PageViewEvent{PageID: strconv.Itoa(i)}}
+	}
+	return s
+}
+
+func (s *fakeSource) Events() <-chan generator.Event { return s.events }
+
+func (s *fakeSource) Close() {
+	s.log.add("source")
+	close(s.events)
+}
+
+// stage промежуточный компонент: накапливает события и передает их
+// следующему этапу только при закрытии, как Publisher и Batcher.
+type stage struct {
+	name string
+	log  *closeLog
+	next func([]event.PageViewEvent)
+	err  error
+
+	mu      sync.Mutex
+	pending []event.PageViewEvent
+	// flushed события, сброшенные последним этапом без next
+	flushed []event.PageViewEvent
+}
+
+func (s *stage) SendAsync(ctx context.Context, message event.PageViewEvent, callback publisher.AsyncCallback[event.PageViewEvent]) error {
+	s.push([]event.PageViewEvent{message})
+	return nil
+}
+
+func (s *stage) push(events []event.PageViewEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, events...)
+}
+
+func (s *stage) flush() {
+	s.log.add(s.name)
+
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if s.next == nil {
+		s.flushed = append(s.flushed, pending...)
+		return
+	}
+
+	s.next(pending)
+}
+
+// stagePublisher адаптирует stage к Publisher.
+type stagePublisher struct{ *stage }
+
+func (p stagePublisher) Close() error {
+	p.flush()
+	return p.err
+}
+
+// stageFlusher адаптирует stage к Flusher.
+type stageFlusher struct{ *stage }
+
+func (f stageFlusher) Close() { f.flush() }
+
+// stageCloser адаптирует stage к io.Closer.
+type stageCloser struct{ *stage }
+
+func (c stageCloser) Close() error {
+	c.flush()
+	return c.err
+}
+
+func TestPipeline_ShutdownOrder(t *testing.T) {
+	const count = 100
+
+	log := &closeLog{}
+	source := newFakeSource(log, count)
+
+	conn := &stage{name: "conn", log: log}
+	batcher := &stage{name: "batcher", log: log, next: conn.push}
+	pub := &stage{name: "publisher", log: log, next: batcher.push}
+
+	p := NewPipeline(source, stagePublisher{pub},
+		WithFlushers(stageFlusher{batcher}),
+		WithClosers(stageCloser{conn}),
+	)
+
+	runDone := make(chan error, 1)
+	go func() {
+		runDone <- p.Run(context.Background())
+	}()
+
+	// ждем, пока Run начнет читать источник
+	assert.Eventually(t, func() bool { return len(source.events) < count }, time.Second, time.Millisecond)
+
+	assert.NoError(t, p.Shutdown(t.Context()))
+	assert.NoError(t, <-runDone)
+
+	assert.Equal(t, []string{"source", "publisher", "batcher", "conn"}, log.get())
+
+	// все события источника дошли до соединения
+	assert.Len(t, conn.flushed, count)
+	for i, e := range conn.flushed {
+		assert.Equal(t, strconv.Itoa(i), e.PageID)
+	}
+
+	assert.ErrorIs(t, p.Shutdown(t.Context()), ErrClosed)
+	assert.ErrorIs(t, p.Run(context.Background()), ErrClosed)
+}
+
+func TestPipeline_ShutdownBeforeRun(t *testing.T) {
+	log := &closeLog{}
+	source := newFakeSource(log, 1)
+	pub := &stage{name: "publisher", log: log}
+
+	p := NewPipeline(source, stagePublisher{pub})
+
+	assert.NoError(t, p.Shutdown(t.Context()))
+	assert.ErrorIs(t, p.Run(context.Background()), ErrClosed)
+	assert.Equal(t, []string{"source", "publisher"}, log.get())
+}
+
+func TestPipeline_ShutdownJoinsErrors(t *testing.T) {
+	errPublisher := errors.New("publisher failed")
+	errConn := errors.New("conn failed")
+
+	log := &closeLog{}
+	source := newFakeSource(log, 0)
+	pub := &stage{name: "publisher", log: log, err: errPublisher}
+	conn := &stage{name: "conn", log: log, err: errConn}
+
+	p := NewPipeline(source, stagePublisher{pub}, WithClosers(stageCloser{conn}))
+
+	err := p.Shutdown(t.Context())
+	assert.ErrorIs(t, err, errPublisher)
+	assert.ErrorIs(t, err, errConn)
+	assert.Equal(t, []string{"source", "publisher", "conn"}, log.get())
+}
+
+// blockingSource не закрывает канал событий в Close.
+type blockingSource struct {
+	fakeSource
+}
+
+func (s *blockingSource) Close() {
+	s.log.add("source")
+}
+
+func TestPipeline_ShutdownContextExpired(t *testing.T) {
+	log := &closeLog{}
+	source := &blockingSource{fakeSource{log: log, events: make(chan generator.Event)}}
+	pub := &stage{name: "publisher", log: log}
+
+	p := NewPipeline(source, stagePublisher{pub})
+	go func() { _ = p.Run(context.Background()) }()
+	assert.Eventually(t, p.started.Load, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Equal(t, []string{"source", "publisher"}, log.get())
+}
+
+// recordingWriter запоминает записанные Kafka-сообщения.
+type recordingWriter struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+}
+
+func (w *recordingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *recordingWriter) Close() error { return nil }
+
+func TestPipeline_TombstoneWrittenWithNilValue(t *testing.T) {
+	log := &closeLog{}
+	source := &fakeSource{log: log, events: make(chan generator.Event, 2)}
+
+	ev := event.PageViewEvent{PageID: "page", UserID: "user", ViewDuration: 100, Timestamp: time.Now()}
+	source.events <- generator.Event{Event: ev}
+	source.events <- generator.Event{
+		Event: event.PageViewEvent{PageID: ev.PageID, UserID: ev.UserID},
+		Meta:  generator.Meta{IsTombstone: true},
+	}
+
+	w := &recordingWriter{}
+	s := sender.NewKafkaSender(w)
+
+	pub, err := publisher.NewPublisher[event.PageViewEvent](t.Context(), s.WriteFn, 1, 2)
+	assert.NoError(t, err)
+
+	p := NewPipeline(source, pub)
+	go func() { _ = p.Run(t.Context()) }()
+
+	assert.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return len(w.messages) == 2
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, p.Shutdown(t.Context()))
+
+	assert.NotNil(t, w.messages[0].Value)
+	assert.Equal(t, w.messages[0].Key, w.messages[1].Key)
+	assert.Nil(t, w.messages[1].Value)
+}
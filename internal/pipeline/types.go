@@ -0,0 +1,28 @@
+package pipeline
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/generator"
+	"ay-events-generator/internal/publisher"
+	"context"
+)
+
+// Source источник событий, например *generator.EventGenerator.
+// После Close канал Events должен быть закрыт.
+type Source interface {
+	Events() <-chan generator.Event
+	Close()
+}
+
+// Publisher принимает события на асинхронную запись, например *publisher.Publisher.
+// Close дообрабатывает очередь перед возвратом.
+type Publisher interface {
+	SendAsync(ctx context.Context, message event.PageViewEvent, callback publisher.AsyncCallback[event.PageViewEvent]) error
+	Close() error
+}
+
+// Flusher промежуточный буфер, отправляющий остаток при Close,
+// например *producer_batcher.Batcher.
+type Flusher interface {
+	Close()
+}
@@ -0,0 +1,58 @@
+package producer_batcher
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// batchAck собирает результаты сообщений одного flush и вызывает completeFn,
+// когда по каждому сообщению батча получен результат.
+// Учитывается только первый результат сообщения, повторные вызовы callback
+// (например, при ретраях) на итоговые счетчики не влияют.
+type batchAck struct {
+	completeFn BatchCompleteFn
+	remaining  atomic.Int64
+	succeeded  atomic.Int64
+	failed     atomic.Int64
+}
+
+// wrapMessages подменяет callback каждого сообщения на учитывающий результат.
+// Исходный callback (если задан) вызывается как и прежде.
+func wrapMessages[T any](messages []Message[T], completeFn BatchCompleteFn) {
+	if completeFn == nil || len(messages) == 0 {
+		return
+	}
+
+	ack := &batchAck{completeFn: completeFn}
+	ack.remaining.Store(int64(len(messages)))
+
+	for i := range messages {
+		original := messages[i].Callback
+		once := &sync.Once{}
+
+		messages[i].Callback = func(ctx context.Context, message T, err error) {
+			once.Do(func() {
+				ack.done(err)
+			})
+
+			if original != nil {
+				original(ctx, message, err)
+			}
+		}
+	}
+}
+
+// done учитывает результат одного сообщения и вызывает completeFn
+// после получения результата по последнему сообщению батча.
+func (a *batchAck) done(err error) {
+	if err != nil {
+		a.failed.Add(1)
+	} else {
+		a.succeeded.Add(1)
+	}
+
+	if a.remaining.Add(-1) == 0 {
+		a.completeFn(int(a.succeeded.Load()), int(a.failed.Load()))
+	}
+}
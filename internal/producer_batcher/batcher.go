@@ -1,6 +1,7 @@
 package producer_batcher
 
 import (
+	"ay-events-generator/internal/clock"
 	"context"
 	"errors"
 	"sync"
@@ -16,43 +17,133 @@ type Batcher[T any] struct {
 	flushSize uint
 	flushFn   Flush[T]
 
-	buffer []Message[T]
-	mutex  sync.Mutex
+	flushTimeCh chan time.Duration
+
+	flushTimeout time.Duration
+	flushJitter  float64
+
+	batchCompleteFn BatchCompleteFn
+
+	flushPanicListeners []FlushPanicListener
+
+	buffer    []Message[T]
+	bufferMax uint
+	mutex     sync.Mutex
+
+	overflow OverflowPolicy
+	spaceCh  chan struct{}
 
 	stopCh  chan struct{}
 	wg      sync.WaitGroup
+	flushWg sync.WaitGroup
 	stopped atomic.Bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	clock  clock.Clock
+	logger *zap.Logger
 }
 
 // NewBatcher создает новый батчер с функцией flushFn.
-func NewBatcher[T any](flushFn Flush[T]) (*Batcher[T], error) {
+func NewBatcher[T any](flushFn Flush[T], opts ...Option[T]) (*Batcher[T], error) {
 	if flushFn == nil {
 		return nil, errors.New("flush function not found")
 	}
 
 	b := &Batcher[T]{
-		mode:      defaultMode,
-		flushTime: defaultFlushTime,
-		flushSize: defaultFlushSize,
-		flushFn:   flushFn,
-		buffer:    make([]Message[T], 0, bufferSize),
-		stopCh:    make(chan struct{}),
+		mode:        defaultMode,
+		flushTime:   defaultFlushTime,
+		flushSize:   defaultFlushSize,
+		flushFn:     flushFn,
+		flushTimeCh: make(chan time.Duration, 1),
+		buffer:      make([]Message[T], 0, bufferSize),
+		bufferMax:   bufferSize,
+		overflow:    defaultOverflowPolicy,
+		spaceCh:     make(chan struct{}),
+		clock:       clock.Real(),
+		logger:      zap.L(),
 	}
 
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+
 	b.start()
 	return b, nil
 }
 
-// SetFlushTime устанавливает интервал для TimeMode.
+// SetFlushTime устанавливает интервал для TimeMode и HybridMode.
+// Работающий таймер перенастраивается без перезапуска батчера.
 func (b *Batcher[T]) SetFlushTime(duration time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	b.flushTime = duration
+
+	// в канале хранится только последнее значение; ни отправка, ни вычитывание
+	// не блокируются, даже если таймер уже забрал предыдущее значение
+	for {
+		select {
+		case b.flushTimeCh <- duration:
+			return
+		default:
+		}
+
+		select {
+		case <-b.flushTimeCh:
+		default:
+		}
+	}
 }
 
-// SetFlushSize устанавливает размер батча для SizeMode.
+// SetFlushSize устанавливает размер батча для SizeMode и HybridMode.
 func (b *Batcher[T]) SetFlushSize(size uint) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	b.flushSize = size
 }
 
+// SetFlushTimeout ограничивает время одного flush.
+// Контексты сообщений батча получают дедлайн, а сообщения, не обработанные
+// к его наступлению, получают в callback context.DeadlineExceeded.
+// Нулевое значение отключает ограничение.
+func (b *Batcher[T]) SetFlushTimeout(timeout time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.flushTimeout = timeout
+}
+
+// SetBufferMax устанавливает максимальное количество сообщений в буфере.
+// При заполнении буфера Push возвращает ErrBufferFull.
+func (b *Batcher[T]) SetBufferMax(size uint) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.bufferMax = size
+}
+
+// SetBatchCompleteFn задает функцию, вызываемую один раз на каждый flush
+// после получения результатов по всем сообщениям батча.
+func (b *Batcher[T]) SetBatchCompleteFn(fn BatchCompleteFn) {
+	b.batchCompleteFn = fn
+}
+
+// AddFlushPanicListener добавляет слушателя паники во flushFn.
+// Паника перехватывается и не завершает процесс.
+func (b *Batcher[T]) AddFlushPanicListener(fn FlushPanicListener) {
+	b.flushPanicListeners = append(b.flushPanicListeners, fn)
+}
+
+func (b *Batcher[T]) callFlushPanicListeners(recovered any) {
+	for _, fn := range b.flushPanicListeners {
+		fn(recovered)
+	}
+}
+
 // SetMode меняет режим батчинга и перезапускает таймер, если нужно.
 func (b *Batcher[T]) SetMode(mode BatchMode) {
 	if b.mode == mode {
@@ -63,13 +154,49 @@ func (b *Batcher[T]) SetMode(mode BatchMode) {
 }
 
 // Push добавляет сообщение в батчер.
+// Если буфер заполнен и flush еще не освободил место, поведение определяет
+// OverflowPolicy: по умолчанию возвращается ErrBufferFull, что позволяет
+// вызывающей стороне притормозить отправку.
 func (b *Batcher[T]) Push(ctx context.Context, message T, callback Callback[T]) error {
 	if b.stopped.Load() {
-		zap.L().Error(ErrBatchStopped.Error())
+		b.logger.Error(ErrBatchStopped.Error())
 		return ErrBatchStopped
 	}
 
 	b.mutex.Lock()
+	for uint(len(b.buffer)) >= b.bufferMax {
+		switch {
+		case b.overflow == ForceFlush && len(b.buffer) > 0:
+			messages := b.flushBuffer()
+			b.mutex.Unlock()
+			b.goFlush(messages)
+		case b.overflow == BlockUntilFlush && b.mode == SizeMode && b.bufferMax < b.flushSize:
+			// батч никогда не наберется, ожидание flush заблокировалось бы навсегда
+			b.mutex.Unlock()
+			b.logger.Error(ErrFlushSizeExceedsBuffer.Error())
+			return ErrFlushSizeExceedsBuffer
+		case b.overflow == BlockUntilFlush:
+			spaceCh := b.spaceCh
+			b.mutex.Unlock()
+
+			select {
+			case <-spaceCh:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			if b.stopped.Load() {
+				b.logger.Error(ErrBatchStopped.Error())
+				return ErrBatchStopped
+			}
+		default:
+			b.mutex.Unlock()
+			b.logger.Error(ErrBufferFull.Error())
+			return ErrBufferFull
+		}
+		b.mutex.Lock()
+	}
+
 	b.buffer = append(b.buffer, Message[T]{
 		Ctx:      ctx,
 		Data:     message,
@@ -78,84 +205,182 @@ func (b *Batcher[T]) Push(ctx context.Context, message T, callback Callback[T])
 
 	var messages []Message[T]
 	var flushed bool
-	if b.mode == SizeMode && len(b.buffer) >= int(b.flushSize) {
+	if (b.mode == SizeMode || b.mode == HybridMode) && len(b.buffer) >= int(b.flushSize) {
 		messages = b.flushBuffer()
 		flushed = true
 	}
 	b.mutex.Unlock()
 
 	if flushed {
-		go b.flushFn(messages)
+		b.goFlush(messages)
 	}
 
 	return nil
 }
 
-// start запускает таймерную горутину для TimeMode.
+// Reset отбрасывает накопленные сообщения без flush.
+// Callback каждого отброшенного сообщения вызывается с ErrDiscarded.
+func (b *Batcher[T]) Reset() {
+	b.mutex.Lock()
+	messages := make([]Message[T], len(b.buffer))
+	copy(messages, b.buffer)
+	clear(b.buffer)
+	b.buffer = b.buffer[:0]
+	b.notifySpace()
+	b.mutex.Unlock()
+
+	for _, m := range messages {
+		if m.Callback != nil {
+			m.Callback(m.Ctx, m.Data, ErrDiscarded)
+		}
+	}
+}
+
+// Len возвращает количество сообщений, ожидающих flush.
+func (b *Batcher[T]) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return len(b.buffer)
+}
+
+// Cap возвращает максимальное количество сообщений в буфере,
+// при достижении которого Push возвращает ErrBufferFull.
+func (b *Batcher[T]) Cap() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return int(b.bufferMax)
+}
+
+// start запускает таймерную горутину для TimeMode и HybridMode.
 func (b *Batcher[T]) start() {
+	b.mutex.Lock()
+	// батчер запускается заново после Close, Context тоже начинается заново
+	if b.ctx.Err() != nil {
+		b.ctx, b.cancel = context.WithCancel(context.Background())
+	}
+	b.mutex.Unlock()
+
 	b.stopped.Swap(false)
-	if b.mode == TimeMode {
+	b.stopCh = make(chan struct{})
+	if b.mode == TimeMode || b.mode == HybridMode {
 		b.wg.Add(1)
 		go b.timeModeProcess()
 	}
 }
 
-// restart перезапускает батчер.
+// restart перезапускает батчер, не отменяя Context.
 func (b *Batcher[T]) restart() {
-	b.Close()
+	b.stop()
 	b.start()
 }
 
-// timeModeProcess — цикл таймера для TimeMode.
+// timeModeProcess — цикл таймера для TimeMode и HybridMode.
 func (b *Batcher[T]) timeModeProcess() {
 	defer b.wg.Done()
-	ticker := time.NewTicker(b.flushTime)
-	defer ticker.Stop()
+
+	// отложенное значение из SetFlushTime уже учтено в b.flushTime
+	select {
+	case <-b.flushTimeCh:
+	default:
+	}
+
+	b.mutex.Lock()
+	flushTime := b.flushTime
+	b.mutex.Unlock()
+
+	// таймер перезапускается после каждого flush, чтобы интервал
+	// мог меняться с учетом SetFlushJitter
+	timer := b.clock.NewTimer(b.jitteredFlushTime(flushTime))
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case flushTime = <-b.flushTimeCh:
+			timer.Reset(b.jitteredFlushTime(flushTime))
+		case <-timer.C():
 			b.mutex.Lock()
 			messages := b.flushBuffer()
 			b.mutex.Unlock()
 			if len(messages) > 0 {
-				go b.flushFn(messages)
+				b.goFlush(messages)
 			}
+			timer.Reset(b.jitteredFlushTime(flushTime))
 		case <-b.stopCh:
 			b.mutex.Lock()
 			messages := b.flushBuffer()
 			b.mutex.Unlock()
 			if len(messages) > 0 {
-				go b.flushFn(messages)
+				b.goFlush(messages)
 			}
 			return
 		}
 	}
 }
 
+// goFlush запускает flush асинхронно, учитывая его в flushWg,
+// чтобы Close мог дождаться завершения.
+func (b *Batcher[T]) goFlush(messages []Message[T]) {
+	b.flushWg.Add(1)
+	go func() {
+		defer b.flushWg.Done()
+		b.runFlush(messages)
+	}()
+}
+
 // flushBuffer копирует и очищает буфер.
 func (b *Batcher[T]) flushBuffer() []Message[T] {
 	messages := make([]Message[T], len(b.buffer))
 	copy(messages, b.buffer)
 	b.buffer = b.buffer[:0]
+	b.notifySpace()
+	wrapMessages(messages, b.batchCompleteFn)
 	return messages
 }
 
-// Close останавливает батчер и сбрасывает буфер.
+// Context возвращает контекст батчера для работы, запускаемой из flushFn
+// (flushFn получает его через замыкание над батчером).
+// Контекст отменяется в конце Close, после завершения всех flush;
+// смена режима через SetMode его не отменяет.
+func (b *Batcher[T]) Context() context.Context {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.ctx
+}
+
+// Close останавливает батчер, сбрасывает буфер и дожидается
+// завершения всех начатых асинхронных flush, после чего отменяет Context.
 func (b *Batcher[T]) Close() {
+	b.stop()
+
+	b.mutex.Lock()
+	b.cancel()
+	b.mutex.Unlock()
+}
+
+// stop останавливает таймер, сбрасывает буфер и дожидается
+// завершения всех начатых асинхронных flush.
+func (b *Batcher[T]) stop() {
 	if b.stopped.Swap(true) {
 		return
 	}
 
-	if b.mode == TimeMode {
-		close(b.stopCh)
-		b.wg.Wait()
-	} else if b.mode == SizeMode {
-		b.mutex.Lock()
-		messages := b.flushBuffer()
-		b.mutex.Unlock()
-		if len(messages) > 0 {
-			b.flushFn(messages)
-		}
+	close(b.stopCh)
+	b.wg.Wait()
+
+	b.mutex.Lock()
+	var messages []Message[T]
+	if b.mode == SizeMode {
+		messages = b.flushBuffer()
+	} else {
+		b.notifySpace()
 	}
+	b.mutex.Unlock()
+	if len(messages) > 0 {
+		b.runFlush(messages)
+	}
+
+	b.flushWg.Wait()
 }
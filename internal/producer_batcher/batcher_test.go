@@ -1,8 +1,12 @@
 package producer_batcher_test
 
 import (
+	"ay-events-generator/internal/clock"
 	"ay-events-generator/internal/producer_batcher"
 	"context"
+	"errors"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -89,3 +93,745 @@ func TestPushAfterClose(t *testing.T) {
 		t.Errorf("expected flushFn not to be called after Close")
 	}
 }
+
+// TestBatchCompleteFn проверяет, что на частично неуспешный flush
+// callback завершения батча вызывается один раз с корректными счетчиками.
+func TestBatchCompleteFn(t *testing.T) {
+	flushFn := func(batch []producer_batcher.Message[int]) {
+		for _, m := range batch {
+			var err error
+			if m.Data%2 == 0 {
+				err = errors.New("write failed")
+			}
+			m.Callback(m.Ctx, m.Data, err)
+		}
+	}
+
+	type result struct{ succeeded, failed int }
+	results := make(chan result, 2)
+
+	b, _ := producer_batcher.NewBatcher[int](flushFn)
+	b.SetMode(producer_batcher.SizeMode)
+	b.SetFlushSize(5)
+	b.SetBatchCompleteFn(func(succeeded, failed int) {
+		results <- result{succeeded, failed}
+	})
+
+	var called atomic.Int32
+	for i := range 5 {
+		_ = b.Push(context.Background(), i, func(ctx context.Context, message int, err error) {
+			called.Add(1)
+		})
+	}
+
+	select {
+	case r := <-results:
+		if r.succeeded != 2 || r.failed != 3 {
+			t.Errorf("expected 2 succeeded and 3 failed, got %d and %d", r.succeeded, r.failed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("batch complete callback was not called")
+	}
+
+	if called.Load() != 5 {
+		t.Errorf("expected message callbacks to be called 5 times, got %d", called.Load())
+	}
+
+	select {
+	case <-results:
+		t.Error("batch complete callback was called more than once")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestTimeModeFlushFakeClock проверяет flush по таймеру TimeMode
+// с управляемыми часами, без реального ожидания.
+func TestTimeModeFlushFakeClock(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	flushed := make(chan int, 1)
+
+	b, _ := producer_batcher.NewBatcher[int](func(batch []producer_batcher.Message[int]) {
+		flushed <- len(batch)
+	}, producer_batcher.WithClock[int](clk))
+	b.SetFlushTime(time.Minute)
+	b.SetMode(producer_batcher.TimeMode)
+
+	_ = b.Push(context.Background(), 1, nil)
+	_ = b.Push(context.Background(), 2, nil)
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+
+	select {
+	case n := <-flushed:
+		if n != 2 {
+			t.Errorf("expected 2 messages in batch, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("flushFn was not called after advancing the clock")
+	}
+
+	b.Close()
+}
+
+// TestPushBufferFull проверяет, что Push возвращает ErrBufferFull при заполненном буфере.
+func TestPushBufferFull(t *testing.T) {
+	b, _ := producer_batcher.NewBatcher[int](func(batch []producer_batcher.Message[int]) {})
+	b.SetFlushTime(time.Hour)
+	b.SetMode(producer_batcher.TimeMode)
+	b.SetBufferMax(3)
+	defer b.Close()
+
+	for i := range 3 {
+		if err := b.Push(context.Background(), i, nil); err != nil {
+			t.Fatalf("unexpected error on push %d: %v", i, err)
+		}
+	}
+
+	if err := b.Push(context.Background(), 3, nil); !errors.Is(err, producer_batcher.ErrBufferFull) {
+		t.Fatalf("expected ErrBufferFull, got %v", err)
+	}
+}
+
+// TestReset проверяет, что Reset отбрасывает буфер и сообщает об этом в callback.
+func TestReset(t *testing.T) {
+	var flushedCount atomic.Int32
+	flushFn := func(batch []producer_batcher.Message[int]) {
+		flushedCount.Add(int32(len(batch)))
+	}
+
+	b, _ := producer_batcher.NewBatcher[int](flushFn)
+	b.SetMode(producer_batcher.SizeMode)
+	b.SetFlushSize(10)
+
+	var discarded atomic.Int32
+	for i := range 3 {
+		_ = b.Push(context.Background(), i, func(ctx context.Context, message int, err error) {
+			if errors.Is(err, producer_batcher.ErrDiscarded) {
+				discarded.Add(1)
+			}
+		})
+	}
+
+	b.Reset()
+
+	if discarded.Load() != 3 {
+		t.Errorf("expected 3 discarded callbacks, got %d", discarded.Load())
+	}
+
+	b.Close()
+	if flushedCount.Load() != 0 {
+		t.Errorf("expected empty flush after Reset, got %d messages", flushedCount.Load())
+	}
+}
+
+// TestFlushTimeout проверяет, что при медленном flushFn сообщения получают ошибку дедлайна.
+func TestFlushTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var ctxDone atomic.Bool
+	flushFn := func(batch []producer_batcher.Message[int]) {
+		// как и настоящий sink, flushFn сообщает ошибку отмененного контекста
+		for _, m := range batch {
+			select {
+			case <-m.Ctx.Done():
+				ctxDone.Store(true)
+			case <-release:
+			}
+			m.Callback(m.Ctx, m.Data, m.Ctx.Err())
+		}
+	}
+
+	b, _ := producer_batcher.NewBatcher[int](flushFn)
+	b.SetMode(producer_batcher.SizeMode)
+	b.SetFlushSize(2)
+	b.SetFlushTimeout(20 * time.Millisecond)
+
+	errs := make(chan error, 4)
+	for i := range 2 {
+		_ = b.Push(context.Background(), i, func(ctx context.Context, message int, err error) {
+			errs <- err
+		})
+	}
+
+	for range 2 {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("callback was not called after flush timeout")
+		}
+	}
+
+	// поздний результат flushFn не должен повторно вызывать callback
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case err := <-errs:
+		t.Fatalf("unexpected late callback with %v", err)
+	default:
+	}
+
+	if !ctxDone.Load() {
+		t.Error("expected flush context to be canceled by the deadline")
+	}
+}
+
+// TestCloseWaitsForAsyncFlushes проверяет, что Close дожидается
+// асинхронных flush, запущенных Push в SizeMode.
+func TestCloseWaitsForAsyncFlushes(t *testing.T) {
+	var completed int32
+	flushFn := func(batch []producer_batcher.Message[int]) {
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&completed, 1)
+	}
+
+	b, _ := producer_batcher.NewBatcher[int](flushFn)
+	b.SetMode(producer_batcher.SizeMode)
+	b.SetFlushSize(2)
+
+	for i := range 6 {
+		_ = b.Push(context.Background(), i, nil)
+	}
+
+	b.Close()
+
+	if got := atomic.LoadInt32(&completed); got != 3 {
+		t.Errorf("expected 3 flushes to complete before Close returns, got %d", got)
+	}
+}
+
+// TestHybridModeFlush проверяет, что HybridMode сбрасывает батч
+// как при достижении flushSize, так и по таймеру.
+func TestHybridModeFlush(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	flushed := make(chan int, 2)
+
+	b, _ := producer_batcher.NewBatcher[int](func(batch []producer_batcher.Message[int]) {
+		flushed <- len(batch)
+	}, producer_batcher.WithClock[int](clk))
+	b.SetFlushSize(3)
+	b.SetFlushTime(time.Minute)
+	b.SetMode(producer_batcher.HybridMode)
+
+	for i := range 3 {
+		_ = b.Push(context.Background(), i, nil)
+	}
+
+	select {
+	case n := <-flushed:
+		if n != 3 {
+			t.Errorf("expected 3 messages in size-triggered batch, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("flushFn was not called after reaching flush size")
+	}
+
+	_ = b.Push(context.Background(), 3, nil)
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+
+	select {
+	case n := <-flushed:
+		if n != 1 {
+			t.Errorf("expected 1 message in time-triggered batch, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("flushFn was not called after advancing the clock")
+	}
+
+	b.Close()
+}
+
+// TestFlushPanicRecovered проверяет, что паника во flushFn перехватывается,
+// передается слушателю и не мешает последующим flush.
+func TestFlushPanicRecovered(t *testing.T) {
+	var calls atomic.Int32
+	flushFn := func(batch []producer_batcher.Message[int]) {
+		if calls.Add(1) == 1 {
+			var m map[int]int
+			m[batch[0].Data] = 1
+		}
+	}
+
+	b, _ := producer_batcher.NewBatcher[int](flushFn)
+	b.SetMode(producer_batcher.SizeMode)
+	b.SetFlushSize(1)
+
+	recovered := make(chan any, 1)
+	b.AddFlushPanicListener(func(r any) {
+		recovered <- r
+	})
+
+	_ = b.Push(context.Background(), 1, nil)
+
+	select {
+	case r := <-recovered:
+		if r == nil {
+			t.Error("expected non-nil recovered value")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("flush panic listener was not called")
+	}
+
+	_ = b.Push(context.Background(), 2, nil)
+	b.Close()
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected flushFn to be called twice, got %d", got)
+	}
+}
+
+// TestCallbackReceivesMessageContext проверяет, что callback каждого сообщения
+// получает значения собственного контекста, в том числе с flushTimeout.
+func TestCallbackReceivesMessageContext(t *testing.T) {
+	type ctxKey struct{}
+
+	flushFn := func(batch []producer_batcher.Message[int]) {
+		for _, m := range batch {
+			m.Callback(m.Ctx, m.Data, nil)
+		}
+	}
+
+	for _, timeout := range []time.Duration{0, time.Second} {
+		b, _ := producer_batcher.NewBatcher[int](flushFn)
+		b.SetMode(producer_batcher.SizeMode)
+		b.SetFlushSize(3)
+		b.SetFlushTimeout(timeout)
+
+		mismatches := make(chan int, 3)
+		var called atomic.Int32
+		for i := range 3 {
+			ctx := context.WithValue(context.Background(), ctxKey{}, i)
+			_ = b.Push(ctx, i, func(ctx context.Context, message int, err error) {
+				called.Add(1)
+				if v, _ := ctx.Value(ctxKey{}).(int); v != message {
+					mismatches <- message
+				}
+			})
+		}
+
+		b.Close()
+
+		if called.Load() != 3 {
+			t.Fatalf("timeout %v: expected 3 callbacks, got %d", timeout, called.Load())
+		}
+		select {
+		case m := <-mismatches:
+			t.Fatalf("timeout %v: callback of message %d received foreign context", timeout, m)
+		default:
+		}
+	}
+}
+
+// TestLenCap проверяет, что Len отражает количество сообщений до flush,
+// а Cap — максимальный размер буфера.
+func TestLenCap(t *testing.T) {
+	b, _ := producer_batcher.NewBatcher[int](func(batch []producer_batcher.Message[int]) {})
+	defer b.Close()
+
+	b.SetMode(producer_batcher.SizeMode)
+	b.SetFlushSize(5)
+	b.SetBufferMax(10)
+
+	for i := range 3 {
+		_ = b.Push(context.Background(), i, nil)
+	}
+
+	if got := b.Len(); got != 3 {
+		t.Errorf("expected Len 3, got %d", got)
+	}
+	if got := b.Cap(); got != 10 {
+		t.Errorf("expected Cap 10, got %d", got)
+	}
+
+	b.Reset()
+	if got := b.Len(); got != 0 {
+		t.Errorf("expected Len 0 after Reset, got %d", got)
+	}
+}
+
+// collectBatches возвращает flushFn, сохраняющую данные каждого батча.
+func collectBatches(batches chan []int) producer_batcher.Flush[int] {
+	return func(batch []producer_batcher.Message[int]) {
+		data := make([]int, len(batch))
+		for i, m := range batch {
+			data[i] = m.Data
+		}
+		batches <- data
+	}
+}
+
+// TestOverflowDropAndError проверяет, что при заполненном буфере
+// Push возвращает ErrBufferFull, не затирая накопленные сообщения.
+func TestOverflowDropAndError(t *testing.T) {
+	batches := make(chan []int, 2)
+	b, _ := producer_batcher.NewBatcher[int](collectBatches(batches))
+	b.SetFlushSize(100)
+	b.SetBufferMax(2)
+	b.SetOverflowPolicy(producer_batcher.DropAndError)
+
+	_ = b.Push(context.Background(), 1, nil)
+	_ = b.Push(context.Background(), 2, nil)
+	if err := b.Push(context.Background(), 3, nil); !errors.Is(err, producer_batcher.ErrBufferFull) {
+		t.Fatalf("expected ErrBufferFull, got %v", err)
+	}
+
+	b.Close()
+	if got := <-batches; !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("expected batch [1 2], got %v", got)
+	}
+}
+
+// TestOverflowForceFlush проверяет, что при заполненном буфере
+// накопленные сообщения сбрасываются, а новое принимается.
+func TestOverflowForceFlush(t *testing.T) {
+	batches := make(chan []int, 2)
+	b, _ := producer_batcher.NewBatcher[int](collectBatches(batches))
+	b.SetFlushSize(100)
+	b.SetBufferMax(2)
+	b.SetOverflowPolicy(producer_batcher.ForceFlush)
+
+	for i := 1; i <= 3; i++ {
+		if err := b.Push(context.Background(), i, nil); err != nil {
+			t.Fatalf("push %d: %v", i, err)
+		}
+	}
+
+	b.Close()
+
+	// принудительный flush асинхронный, порядок батчей не гарантирован
+	got := [][]int{<-batches, <-batches}
+	slices.SortFunc(got, func(a, b []int) int { return a[0] - b[0] })
+	if !slices.Equal(got[0], []int{1, 2}) || !slices.Equal(got[1], []int{3}) {
+		t.Errorf("expected batches [1 2] and [3], got %v", got)
+	}
+}
+
+// TestOverflowBlockUntilFlush проверяет, что Push ожидает flush
+// при заполненном буфере и принимает сообщение после него.
+func TestOverflowBlockUntilFlush(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	batches := make(chan []int, 2)
+	b, _ := producer_batcher.NewBatcher[int](collectBatches(batches), producer_batcher.WithClock[int](clk))
+	b.SetFlushTime(time.Minute)
+	b.SetMode(producer_batcher.TimeMode)
+	b.SetBufferMax(2)
+	b.SetOverflowPolicy(producer_batcher.BlockUntilFlush)
+
+	_ = b.Push(context.Background(), 1, nil)
+	_ = b.Push(context.Background(), 2, nil)
+
+	pushed := make(chan error, 1)
+	go func() {
+		pushed <- b.Push(context.Background(), 3, nil)
+	}()
+
+	select {
+	case err := <-pushed:
+		t.Fatalf("push must block until flush, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+
+	select {
+	case err := <-pushed:
+		if err != nil {
+			t.Fatalf("expected nil after flush, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("push was not unblocked by flush")
+	}
+
+	b.Close()
+
+	got := [][]int{<-batches, <-batches}
+	slices.SortFunc(got, func(a, b []int) int { return a[0] - b[0] })
+	if !slices.Equal(got[0], []int{1, 2}) || !slices.Equal(got[1], []int{3}) {
+		t.Errorf("expected batches [1 2] and [3], got %v", got)
+	}
+
+	// ожидание прерывается отменой контекста
+	b, _ = producer_batcher.NewBatcher[int](collectBatches(make(chan []int, 1)))
+	b.SetFlushTime(time.Hour)
+	b.SetMode(producer_batcher.TimeMode)
+	b.SetBufferMax(1)
+	b.SetOverflowPolicy(producer_batcher.BlockUntilFlush)
+	_ = b.Push(context.Background(), 1, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Push(ctx, 2, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	b.Close()
+
+	// в SizeMode батч больше буфера не наберется, Push не ждет вечно
+	b, _ = producer_batcher.NewBatcher[int](collectBatches(make(chan []int, 1)))
+	b.SetFlushSize(100)
+	b.SetBufferMax(1)
+	b.SetOverflowPolicy(producer_batcher.BlockUntilFlush)
+	_ = b.Push(context.Background(), 1, nil)
+
+	if err := b.Push(context.Background(), 2, nil); !errors.Is(err, producer_batcher.ErrFlushSizeExceedsBuffer) {
+		t.Errorf("expected ErrFlushSizeExceedsBuffer, got %v", err)
+	}
+	b.Close()
+}
+
+// TestFlushJitter проверяет, что интервалы TimeMode меняются от цикла к циклу
+// и остаются в пределах ±frac от flushTime.
+func TestFlushJitter(t *testing.T) {
+	const (
+		flushTime = 10 * time.Second
+		step      = time.Second
+		cycles    = 10
+	)
+
+	clk := clock.NewFake(time.Unix(0, 0))
+	b, _ := producer_batcher.NewBatcher[int](func(batch []producer_batcher.Message[int]) {},
+		producer_batcher.WithClock[int](clk))
+	defer b.Close()
+
+	b.SetFlushJitter(0.5)
+	b.SetFlushTime(flushTime)
+	b.SetMode(producer_batcher.TimeMode)
+
+	clk.BlockUntil(1)
+
+	intervals := make(map[time.Duration]struct{})
+	last := clk.Now()
+	for range cycles {
+		_ = b.Push(context.Background(), 1, nil)
+
+		// после срабатывания таймер перезапускается, BlockUntil дожидается этого
+		for b.Len() > 0 {
+			clk.Advance(step)
+			clk.BlockUntil(1)
+		}
+
+		interval := clk.Now().Sub(last)
+		if interval < flushTime/2 || interval > flushTime*3/2 {
+			t.Fatalf("interval %v is out of jitter band", interval)
+		}
+		intervals[interval] = struct{}{}
+		last = clk.Now()
+	}
+
+	if len(intervals) < 2 {
+		t.Errorf("expected flush intervals to vary, got %v", intervals)
+	}
+}
+
+// TestFlushAll проверяет, что FlushAll сбрасывает буферы всех батчеров
+// до возврата и объединяет ошибки остановленных.
+func TestFlushAll(t *testing.T) {
+	const count = 3
+
+	var flushed [count]atomic.Int32
+	batchers := make([]*producer_batcher.Batcher[int], count)
+	for i := range count {
+		b, _ := producer_batcher.NewBatcher[int](func(batch []producer_batcher.Message[int]) {
+			flushed[i].Add(int32(len(batch)))
+		})
+		b.SetFlushSize(100)
+		batchers[i] = b
+
+		for j := range i + 1 {
+			_ = b.Push(context.Background(), j, nil)
+		}
+	}
+
+	if err := producer_batcher.FlushAll(batchers...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range count {
+		if got := flushed[i].Load(); got != int32(i+1) {
+			t.Errorf("batcher %d: expected %d flushed messages, got %d", i, i+1, got)
+		}
+		if got := batchers[i].Len(); got != 0 {
+			t.Errorf("batcher %d: expected empty buffer, got %d", i, got)
+		}
+	}
+
+	batchers[0].Close()
+	err := producer_batcher.FlushAll(batchers...)
+	if !errors.Is(err, producer_batcher.ErrBatchStopped) {
+		t.Errorf("expected ErrBatchStopped, got %v", err)
+	}
+
+	for _, b := range batchers[1:] {
+		b.Close()
+	}
+}
+
+// TestCloseWaitsForTimeModeFlush проверяет, что Close дожидается flush,
+// запущенного таймером TimeMode, и только затем отменяет Context.
+func TestCloseWaitsForTimeModeFlush(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var b *producer_batcher.Batcher[int]
+	var aliveDuringFlush atomic.Bool
+	b, _ = producer_batcher.NewBatcher[int](func(batch []producer_batcher.Message[int]) {
+		close(started)
+		<-release
+		aliveDuringFlush.Store(b.Context().Err() == nil)
+	}, producer_batcher.WithClock[int](clk))
+	b.SetFlushTime(time.Minute)
+	b.SetMode(producer_batcher.TimeMode)
+
+	_ = b.Push(context.Background(), 1, nil)
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		b.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned while TimeMode flush was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return after flush completed")
+	}
+
+	if !aliveDuringFlush.Load() {
+		t.Error("expected Context to be alive during flush")
+	}
+	if !errors.Is(b.Context().Err(), context.Canceled) {
+		t.Errorf("expected Context to be canceled after Close, got %v", b.Context().Err())
+	}
+}
+
+// TestContextSurvivesSetMode проверяет, что смена режима не отменяет Context,
+// а отменяет его только Close.
+func TestContextSurvivesSetMode(t *testing.T) {
+	b, _ := producer_batcher.NewBatcher[int](func(batch []producer_batcher.Message[int]) {})
+
+	ctx := b.Context()
+	b.SetMode(producer_batcher.TimeMode)
+	b.SetMode(producer_batcher.SizeMode)
+
+	if ctx.Err() != nil {
+		t.Fatalf("expected Context to survive SetMode, got %v", ctx.Err())
+	}
+	if b.Context() != ctx {
+		t.Fatal("expected SetMode to keep the same Context")
+	}
+
+	b.Close()
+
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("expected Context to be canceled after Close, got %v", ctx.Err())
+	}
+}
+
+// TestSetFlushTimeConcurrent проверяет, что параллельные SetFlushTime
+// не блокируются, даже когда таймер не вычитывает значения.
+func TestSetFlushTimeConcurrent(t *testing.T) {
+	b, _ := producer_batcher.NewBatcher[int](func(batch []producer_batcher.Message[int]) {})
+	defer b.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var wg sync.WaitGroup
+		for i := range 1000 {
+			wg.Go(func() {
+				b.SetFlushTime(time.Duration(i+1) * time.Second)
+			})
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("SetFlushTime blocked")
+	}
+}
+
+// TestFlushTimeoutFakeClockWaitsAbandonedFlush проверяет, что дедлайны сообщений
+// и таймаут flush идут по часам батчера, а Close дожидается flushFn,
+// оставленного по таймауту.
+func TestFlushTimeoutFakeClockWaitsAbandonedFlush(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	ctxDone := make(chan struct{})
+	release := make(chan struct{})
+	var finished atomic.Bool
+
+	b, _ := producer_batcher.NewBatcher[int](func(batch []producer_batcher.Message[int]) {
+		<-batch[0].Ctx.Done()
+		close(ctxDone)
+		<-release
+		finished.Store(true)
+	}, producer_batcher.WithClock[int](clk))
+	b.SetMode(producer_batcher.SizeMode)
+	b.SetFlushSize(1)
+	b.SetFlushTimeout(time.Minute)
+
+	errs := make(chan error, 1)
+	_ = b.Push(context.Background(), 1, func(ctx context.Context, message int, err error) {
+		errs <- err
+	})
+
+	// таймер дедлайна сообщения и таймер flush
+	clk.BlockUntil(2)
+	clk.Advance(time.Minute)
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("callback was not called after flush timeout")
+	}
+
+	select {
+	case <-ctxDone:
+	case <-time.After(time.Second):
+		t.Fatal("message context was not canceled by the fake clock")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		b.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned while flushFn is still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return after flushFn finished")
+	}
+	if !finished.Load() {
+		t.Error("expected flushFn to finish before Close returned")
+	}
+}
@@ -9,4 +9,6 @@ const (
 	defaultFlushSize           = 30
 	defaultMode      BatchMode = SizeMode
 	bufferSize                 = 8192
+	// maxFlushJitter предельный разброс интервала flush, оставляющий его положительным
+	maxFlushJitter = 0.9
 )
@@ -4,4 +4,8 @@ import "errors"
 
 var (
 	ErrBatchStopped = errors.New("batch is stopped")
+	ErrBufferFull   = errors.New("batch buffer is full")
+	ErrDiscarded    = errors.New("message discarded")
+
+	ErrFlushSizeExceedsBuffer = errors.New("flush size exceeds buffer max")
 )
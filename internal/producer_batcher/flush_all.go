@@ -0,0 +1,43 @@
+package producer_batcher
+
+import (
+	"errors"
+	"sync"
+)
+
+// FlushNow синхронно сбрасывает накопленные сообщения и возвращается
+// после завершения flushFn. Ранее запущенные асинхронные flush не ожидаются.
+// Возвращает ErrBatchStopped, если батчер остановлен.
+func (b *Batcher[T]) FlushNow() error {
+	if b.stopped.Load() {
+		return ErrBatchStopped
+	}
+
+	b.mutex.Lock()
+	messages := b.flushBuffer()
+	b.mutex.Unlock()
+
+	if len(messages) > 0 {
+		b.runFlush(messages)
+	}
+
+	return nil
+}
+
+// FlushAll параллельно вызывает FlushNow у всех батчеров, дожидается
+// завершения и возвращает errors.Join их ошибок.
+func FlushAll[T any](batchers ...*Batcher[T]) error {
+	errs := make([]error, len(batchers))
+
+	var wg sync.WaitGroup
+	wg.Add(len(batchers))
+	for i, b := range batchers {
+		go func() {
+			defer wg.Done()
+			errs[i] = b.FlushNow()
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
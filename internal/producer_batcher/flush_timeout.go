@@ -0,0 +1,88 @@
+package producer_batcher
+
+import (
+	"ay-events-generator/internal/clock"
+	"context"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// callFlush вызывает flushFn, перехватывая панику: она логируется
+// и передается слушателям FlushPanicListener.
+func (b *Batcher[T]) callFlush(messages []Message[T]) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("flush panic", zap.Any("recovered", r))
+			b.callFlushPanicListeners(r)
+		}
+	}()
+
+	b.flushFn(messages)
+}
+
+// runFlush вызывает flushFn для батча.
+// Если задан flushTimeout, контексты сообщений получают дедлайн по часам батчера,
+// а сообщениям, не получившим результат к дедлайну, в callback
+// передается context.DeadlineExceeded. Более поздние результаты flushFn игнорируются.
+// Оставленный по таймауту flushFn учитывается в flushWg до своего завершения,
+// поэтому Close дожидается и его.
+func (b *Batcher[T]) runFlush(messages []Message[T]) {
+	b.mutex.Lock()
+	timeout := b.flushTimeout
+	b.mutex.Unlock()
+
+	if timeout <= 0 {
+		b.callFlush(messages)
+		return
+	}
+
+	reported := make([]atomic.Bool, len(messages))
+	original := make([]Message[T], len(messages))
+	copy(original, messages)
+
+	for i := range messages {
+		ctx := messages[i].Ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		ctx, cancel := clock.WithTimeout(ctx, b.clock, timeout)
+		defer cancel()
+
+		callback := messages[i].Callback
+		messages[i].Ctx = ctx
+		messages[i].Callback = func(ctx context.Context, message T, err error) {
+			if reported[i].Swap(true) {
+				return
+			}
+			if callback != nil {
+				callback(ctx, message, err)
+			}
+		}
+	}
+
+	done := make(chan struct{})
+	b.flushWg.Add(1)
+	go func() {
+		defer b.flushWg.Done()
+		defer close(done)
+		b.callFlush(messages)
+	}()
+
+	timer := b.clock.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C():
+		b.logger.Error(context.DeadlineExceeded.Error())
+
+		for i, m := range original {
+			if reported[i].Swap(true) || m.Callback == nil {
+				continue
+			}
+			m.Callback(messages[i].Ctx, m.Data, context.DeadlineExceeded)
+		}
+	}
+}
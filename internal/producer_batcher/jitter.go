@@ -0,0 +1,30 @@
+package producer_batcher
+
+import (
+	"math/rand"
+	"time"
+)
+
+// SetFlushJitter задает разброс интервала TimeMode и HybridMode:
+// каждый интервал выбирается случайно в пределах ±frac от flushTime,
+// чтобы батчеры с одинаковым flushTime не сбрасывались одновременно.
+// Значение ограничивается диапазоном [0, maxFlushJitter], 0 отключает разброс.
+func (b *Batcher[T]) SetFlushJitter(frac float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.flushJitter = min(max(frac, 0), maxFlushJitter)
+}
+
+// jitteredFlushTime возвращает интервал до следующего flush с учетом разброса.
+func (b *Batcher[T]) jitteredFlushTime(flushTime time.Duration) time.Duration {
+	b.mutex.Lock()
+	frac := b.flushJitter
+	b.mutex.Unlock()
+
+	if frac == 0 {
+		return flushTime
+	}
+
+	return flushTime + time.Duration((rand.Float64()*2-1)*frac*float64(flushTime))
+}
@@ -5,4 +5,6 @@ type BatchMode string
 const (
 	TimeMode BatchMode = "time"
 	SizeMode           = "size"
+	// HybridMode сбрасывает батч по размеру или по таймеру, в зависимости от того, что наступит раньше
+	HybridMode = "hybrid"
 )
@@ -0,0 +1,26 @@
+package producer_batcher
+
+import (
+	"ay-events-generator/internal/clock"
+
+	"go.uber.org/zap"
+)
+
+// Option настраивает Batcher при создании.
+type Option[T any] func(*Batcher[T])
+
+// WithClock задает источник времени для таймера TimeMode.
+// По умолчанию используются реальные часы.
+func WithClock[T any](clk clock.Clock) Option[T] {
+	return func(b *Batcher[T]) {
+		b.clock = clk
+	}
+}
+
+// WithLogger задает логгер Batcher.
+// По умолчанию используется глобальный zap.L().
+func WithLogger[T any](logger *zap.Logger) Option[T] {
+	return func(b *Batcher[T]) {
+		b.logger = logger
+	}
+}
@@ -0,0 +1,33 @@
+package producer_batcher
+
+// OverflowPolicy определяет поведение Push при заполненном буфере.
+type OverflowPolicy string
+
+const (
+	// DropAndError отклоняет сообщение с ErrBufferFull.
+	DropAndError OverflowPolicy = "drop_and_error"
+	// BlockUntilFlush ожидает, пока flush освободит буфер, отмены ctx или Close.
+	// В SizeMode при bufferMax меньше flushSize flush не наступит,
+	// поэтому Push возвращает ErrFlushSizeExceedsBuffer.
+	BlockUntilFlush OverflowPolicy = "block_until_flush"
+	// ForceFlush запускает flush накопленных сообщений и принимает новое.
+	ForceFlush OverflowPolicy = "force_flush"
+)
+
+const defaultOverflowPolicy = DropAndError
+
+// SetOverflowPolicy задает поведение Push при заполненном буфере.
+// По умолчанию используется DropAndError.
+func (b *Batcher[T]) SetOverflowPolicy(policy OverflowPolicy) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.overflow = policy
+}
+
+// notifySpace будит Push, ожидающие освобождения буфера.
+// Вызывается под mutex.
+func (b *Batcher[T]) notifySpace() {
+	close(b.spaceCh)
+	b.spaceCh = make(chan struct{})
+}
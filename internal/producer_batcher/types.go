@@ -5,3 +5,8 @@ import "context"
 type Callback[T any] = func(ctx context.Context, message T, err error)
 
 type Flush[T any] = func(messages []Message[T])
+
+type BatchCompleteFn = func(succeeded, failed int)
+
+// FlushPanicListener получает значение, восстановленное после паники во flushFn.
+type FlushPanicListener = func(recovered any)
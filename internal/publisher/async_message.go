@@ -10,4 +10,7 @@ type AsyncMessage[T any] struct {
 	Ctx      context.Context
 	Message  T
 	Callback AsyncCallback[T]
+	Err      error // Итоговая ошибка записи для сообщений из FailedMessages
+
+	attempt int // количество уже выполненных повторов записи
 }
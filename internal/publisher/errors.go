@@ -3,5 +3,16 @@ package publisher
 import "errors"
 
 var (
-	ErrClosed = errors.New("closed")
+	ErrClosed             = errors.New("closed")
+	ErrInvalidBufferSize  = errors.New("invalid buffer size")
+	ErrBufferFull         = errors.New("buffer full")
+	ErrInvalidRetry       = errors.New("invalid retry count")
+	ErrPanic              = errors.New("panic recovered")
+	ErrInvalidWorkerCount = errors.New("invalid worker count")
+	ErrInvalidKeyFn       = errors.New("invalid key function")
+
+	ErrInvalidHighWaterMark = errors.New("invalid high water mark")
+	ErrFailedBufferFull     = errors.New("failed messages buffer full")
+
+	ErrUnbufferedHighWaterMark = errors.New("high water mark requires buffered channel")
 )
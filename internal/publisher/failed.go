@@ -0,0 +1,35 @@
+package publisher
+
+// failedBufferSize размер буфера канала FailedMessages
+const failedBufferSize = 1024
+
+// FailedMessages возвращает канал асинхронных сообщений, запись которых
+// не удалась после исчерпания повторов SetAsyncRetry. Поле Err содержит
+// итоговую ошибку, callback сообщения вызывается как обычно.
+// Сообщения направляются в канал только после первого вызова FailedMessages.
+// Если буфер канала заполнен, сообщение отбрасывается.
+// Канал не закрывается.
+func (w *Publisher[T]) FailedMessages() <-chan AsyncMessage[T] {
+	failed := make(chan AsyncMessage[T], failedBufferSize)
+	if !w.failedCh.CompareAndSwap(nil, &failed) {
+		return *w.failedCh.Load()
+	}
+
+	return failed
+}
+
+// toFailed направляет сообщение с итоговой ошибкой в канал FailedMessages.
+func (w *Publisher[T]) toFailed(m AsyncMessage[T], err error) {
+	failed := w.failedCh.Load()
+	if failed == nil {
+		return
+	}
+
+	m.Err = err
+
+	select {
+	case *failed <- m:
+	default:
+		w.logger.Error(ErrFailedBufferFull.Error())
+	}
+}
@@ -0,0 +1,66 @@
+package publisher
+
+// highWaterMark порог заполнения буфера и его обработчики.
+type highWaterMark struct {
+	frac     float64
+	onHigh   func()
+	onNormal func()
+}
+
+// SetHighWaterMark задает порог заполнения буфера асинхронных сообщений
+// как долю frac от его размера. onHigh вызывается, когда количество ожидающих
+// сообщений достигает порога, onNormal — когда оно опускается ниже.
+// Обработчики вызываются синхронно из SendAsync и воркеров вне блокировок Publisher
+// и не должны блокироваться.
+// Возвращает ErrInvalidHighWaterMark, если frac вне (0, 1],
+// и ErrUnbufferedHighWaterMark, если буфер не задан (размер 0).
+func (w *Publisher[T]) SetHighWaterMark(frac float64, onHigh func(), onNormal func()) error {
+	if frac <= 0 || frac > 1 {
+		return ErrInvalidHighWaterMark
+	}
+	if cap(w.buffer.Load().ch) == 0 {
+		return ErrUnbufferedHighWaterMark
+	}
+
+	w.highWater.Store(&highWaterMark{
+		frac:     frac,
+		onHigh:   onHigh,
+		onNormal: onNormal,
+	})
+
+	return nil
+}
+
+// enqueued учитывает сообщение, передаваемое в буфер.
+func (w *Publisher[T]) enqueued() {
+	w.checkHighWater(w.queued.Add(1))
+}
+
+// dequeued учитывает сообщение, покинувшее буфер.
+func (w *Publisher[T]) dequeued() {
+	w.checkHighWater(w.queued.Add(-1))
+}
+
+// checkHighWater вызывает обработчик при пересечении порога в любую сторону.
+// Если буфер уменьшен до 0 через ResizeBuffer, порог не действует
+// и буфер считается ниже него.
+func (w *Publisher[T]) checkHighWater(queued int64) {
+	mark := w.highWater.Load()
+	if mark == nil {
+		return
+	}
+
+	capacity := cap(w.buffer.Load().ch)
+	threshold := mark.frac * float64(capacity)
+
+	if capacity > 0 && float64(queued) >= threshold {
+		if !w.aboveHighWater.Swap(true) && mark.onHigh != nil {
+			mark.onHigh()
+		}
+		return
+	}
+
+	if w.aboveHighWater.Swap(false) && mark.onNormal != nil {
+		mark.onNormal()
+	}
+}
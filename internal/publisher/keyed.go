@@ -0,0 +1,144 @@
+package publisher
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// KeyedPublisher асинхронно записывает сообщения, сохраняя порядок внутри ключа.
+// Сообщение направляется в воркер hash(key) % workerCount, каждый воркер
+// обрабатывает свою очередь в одной горутине. Сообщения с одним ключом
+// записываются в порядке SendAsync, разные ключи обрабатываются параллельно.
+type KeyedPublisher[T any] struct {
+	write      WriteFn[T]
+	keyFn      func(T) string
+	queues     []chan AsyncMessage[T]
+	queueMutex sync.RWMutex
+	senders    sync.WaitGroup // SendAsync, ожидающие места в очереди
+	done       chan struct{}  // Закрывается при остановке
+	wg         sync.WaitGroup
+	closed     atomic.Bool
+	logger     *zap.Logger
+}
+
+// NewKeyedPublisher создает KeyedPublisher с workerCount воркерами,
+// у каждого из которых очередь на queueSize сообщений.
+// При отмене ctx KeyedPublisher останавливается как при Close:
+// новые сообщения не принимаются, воркеры дообрабатывают очереди и завершаются.
+func NewKeyedPublisher[T any](ctx context.Context, write WriteFn[T], keyFn func(T) string, workerCount int, queueSize int) (*KeyedPublisher[T], error) {
+	if workerCount <= 0 {
+		return nil, ErrInvalidWorkerCount
+	}
+	if keyFn == nil {
+		return nil, ErrInvalidKeyFn
+	}
+	if queueSize < 0 {
+		return nil, ErrInvalidBufferSize
+	}
+
+	p := &KeyedPublisher[T]{
+		write:  write,
+		keyFn:  keyFn,
+		queues: make([]chan AsyncMessage[T], workerCount),
+		done:   make(chan struct{}),
+		logger: zap.L(),
+	}
+
+	p.wg.Add(workerCount)
+	for i := range p.queues {
+		p.queues[i] = make(chan AsyncMessage[T], queueSize)
+		go p.worker(p.queues[i])
+	}
+
+	context.AfterFunc(ctx, func() { p.stop() })
+
+	return p, nil
+}
+
+// SendAsync ставит сообщение в очередь воркера, закрепленного за его ключом.
+// Блокируется, если очередь воркера заполнена, до освобождения места,
+// отмены ctx или остановки KeyedPublisher.
+// Callback (если задан) будет вызван после попытки записи.
+// Возвращает ErrClosed, если KeyedPublisher закрыт.
+func (p *KeyedPublisher[T]) SendAsync(ctx context.Context, message T, callback AsyncCallback[T]) error {
+	p.queueMutex.RLock()
+	if p.closed.Load() {
+		p.queueMutex.RUnlock()
+		return ErrClosed
+	}
+	p.senders.Add(1)
+	p.queueMutex.RUnlock()
+	defer p.senders.Done()
+
+	m := AsyncMessage[T]{
+		Ctx:      ctx,
+		Message:  message,
+		Callback: callback,
+	}
+
+	select {
+	case p.queues[p.workerIndex(message)] <- m:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return ErrClosed
+	}
+}
+
+// Close останавливает прием сообщений и дожидается, пока воркеры
+// дообработают очереди. Повторный вызов возвращает ErrClosed.
+func (p *KeyedPublisher[T]) Close() error {
+	stopped := p.stop()
+	p.wg.Wait()
+
+	if !stopped {
+		return ErrClosed
+	}
+
+	return nil
+}
+
+// stop запрещает новые SendAsync, дожидается ожидающих отправителей
+// и закрывает очереди. Возвращает false, если остановка уже выполнялась.
+func (p *KeyedPublisher[T]) stop() bool {
+	p.queueMutex.Lock()
+	if p.closed.Swap(true) {
+		p.queueMutex.Unlock()
+		return false
+	}
+	close(p.done)
+	p.queueMutex.Unlock()
+
+	p.senders.Wait()
+	for _, queue := range p.queues {
+		close(queue)
+	}
+
+	return true
+}
+
+// worker последовательно записывает сообщения своей очереди до ее закрытия.
+func (p *KeyedPublisher[T]) worker(queue <-chan AsyncMessage[T]) {
+	defer p.wg.Done()
+
+	for m := range queue {
+		if err := p.write(m.Ctx, m.Message, m.Callback); err != nil {
+			p.logger.Error(err.Error())
+			if m.Callback != nil {
+				m.Callback(m.Ctx, m.Message, err)
+			}
+		}
+	}
+}
+
+// workerIndex возвращает номер воркера для ключа сообщения.
+func (p *KeyedPublisher[T]) workerIndex(message T) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(p.keyFn(message)))
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
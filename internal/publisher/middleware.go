@@ -0,0 +1,30 @@
+package publisher
+
+import (
+	"context"
+	"time"
+)
+
+// Chain оборачивает base в переданные middleware.
+// Первый middleware становится внешним: при вызове middlewares
+// выполняются в порядке объявления, затем base.
+func Chain[T any](base WriteFn[T], middlewares ...Middleware[T]) WriteFn[T] {
+	write := base
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		write = middlewares[i](write)
+	}
+	return write
+}
+
+// TimingMiddleware измеряет длительность записи и передает ее в observe
+// вместе с результатом записи.
+func TimingMiddleware[T any](observe func(duration time.Duration, err error)) Middleware[T] {
+	return func(next WriteFn[T]) WriteFn[T] {
+		return func(ctx context.Context, message T, callback Callback[T]) error {
+			start := time.Now()
+			err := next(ctx, message, callback)
+			observe(time.Since(start), err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package publisher
+
+import "go.uber.org/zap"
+
+// Option настраивает Publisher при создании.
+type Option[T any] func(*Publisher[T])
+
+// WithLogger задает логгер Publisher.
+// По умолчанию используется глобальный zap.L().
+func WithLogger[T any](logger *zap.Logger) Option[T] {
+	return func(p *Publisher[T]) {
+		p.logger = logger
+	}
+}
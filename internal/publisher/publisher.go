@@ -2,35 +2,78 @@ package publisher
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 
 	"go.uber.org/zap"
 )
 
+// asyncBuffer канал асинхронных сообщений и сигнал его замены в ResizeBuffer.
+type asyncBuffer[T any] struct {
+	ch      chan AsyncMessage[T]
+	resized chan struct{}  // Закрывается, когда буфер заменен новым
+	senders sync.WaitGroup // Отправители, получившие этот буфер
+}
+
 type Publisher[T any] struct {
 	write           WriteFn[T]
-	asyncMessagesCh chan AsyncMessage[T]
+	buffer          atomic.Pointer[asyncBuffer[T]]
+	bufferMutex     sync.RWMutex
+	resizeMutex     sync.Mutex
 	workersFinished chan struct{}
 	closeCh         chan struct{}
 	closed          atomic.Bool
+	logger          *zap.Logger
+	dropped         atomic.Uint64
+	asyncRetry      atomic.Int32
+
+	// workerErrs последняя ошибка записи каждого воркера при дренаже на Close
+	workerErrs []error
+
+	pendingMutex sync.Mutex
+	pending      int
+	drainWaiters []chan struct{}
+
+	// queued количество сообщений в буфере, включая ожидающих места в нем
+	queued         atomic.Int64
+	highWater      atomic.Pointer[highWaterMark]
+	aboveHighWater atomic.Bool
+
+	failedCh atomic.Pointer[chan AsyncMessage[T]]
 }
 
 // NewPublisher создаёт новый Publisher.
 // Инициализирует каналы, запускает указанное количество воркеров
 // и горутину, отслеживающую их завершение.
-func NewPublisher[T any](context context.Context, write WriteFn[T], workerCount int, bufferAsyncMessageSize int) *Publisher[T] {
+// Возвращает ErrInvalidWorkerCount, если воркеров нет: SendAsync заблокировался бы навсегда.
+func NewPublisher[T any](context context.Context, write WriteFn[T], workerCount int, bufferAsyncMessageSize int, opts ...Option[T]) (*Publisher[T], error) {
+	if workerCount <= 0 {
+		return nil, ErrInvalidWorkerCount
+	}
+	if bufferAsyncMessageSize < 0 {
+		return nil, ErrInvalidBufferSize
+	}
+
 	s := &Publisher[T]{
 		write:           write,
-		asyncMessagesCh: make(chan AsyncMessage[T], bufferAsyncMessageSize),
 		workersFinished: make(chan struct{}),
 		closeCh:         make(chan struct{}),
+		logger:          zap.L(),
+		workerErrs:      make([]error, workerCount),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
 
+	s.buffer.Store(newAsyncBuffer[T](bufferAsyncMessageSize))
+
 	wg := &sync.WaitGroup{}
 	wg.Add(workerCount)
-	for range workerCount {
-		go s.worker(context, wg)
+	for i := range workerCount {
+		go s.worker(context, wg, i)
 	}
 
 	go func() {
@@ -38,7 +81,7 @@ func NewPublisher[T any](context context.Context, write WriteFn[T], workerCount
 		close(s.workersFinished)
 	}()
 
-	return s
+	return s, nil
 }
 
 // SendSync отправляет сообщение синхронно.
@@ -51,7 +94,7 @@ func (w *Publisher[T]) SendSync(ctx context.Context, message T) error {
 
 	err := w.write(ctx, message, nil)
 	if err != nil {
-		zap.L().Error(err.Error())
+		w.logger.Error(err.Error())
 		return err
 	}
 
@@ -60,6 +103,8 @@ func (w *Publisher[T]) SendSync(ctx context.Context, message T) error {
 
 // SendAsync отправляет сообщение асинхронно.
 // Сообщение помещается в очередь и обрабатывается воркером.
+// Если буфер заполнен, блокируется до освобождения места, замены буфера
+// в ResizeBuffer или закрытия Publisher.
 // Callback (если задан) будет вызван после попытки записи.
 // Возвращает ошибку, если Publisher закрыт.
 func (w *Publisher[T]) SendAsync(ctx context.Context, message T, callback AsyncCallback[T]) error {
@@ -67,17 +112,137 @@ func (w *Publisher[T]) SendAsync(ctx context.Context, message T, callback AsyncC
 		return ErrClosed
 	}
 
-	w.asyncMessagesCh <- AsyncMessage[T]{
+	w.addPending()
+	w.enqueued()
+
+	m := AsyncMessage[T]{
+		Ctx:      ctx,
+		Message:  message,
+		Callback: callback,
+	}
+
+	for {
+		buf := w.acquireBuffer()
+
+		select {
+		case buf.ch <- m:
+			buf.senders.Done()
+			return nil
+		case <-buf.resized:
+			// буфер заменен, повторяем отправку в новый
+			buf.senders.Done()
+		case <-w.closeCh:
+			buf.senders.Done()
+			w.dequeued()
+			w.donePending()
+			return ErrClosed
+		}
+	}
+}
+
+// TrySendAsync отправляет сообщение асинхронно, не блокируясь.
+// Если буфер заполнен, сообщение отбрасывается, учитывается в Stats().Dropped
+// и возвращается ErrBufferFull.
+func (w *Publisher[T]) TrySendAsync(ctx context.Context, message T, callback AsyncCallback[T]) error {
+	if w.closed.Load() {
+		return ErrClosed
+	}
+
+	w.addPending()
+	w.enqueued()
+
+	m := AsyncMessage[T]{
 		Ctx:      ctx,
 		Message:  message,
 		Callback: callback,
 	}
 
+	if w.tryEnqueue(m) {
+		return nil
+	}
+
+	w.dequeued()
+	w.donePending()
+	w.dropped.Add(1)
+	w.logger.Error(ErrBufferFull.Error())
+	return ErrBufferFull
+}
+
+// SetAsyncRetry задает количество повторов неудачной асинхронной записи.
+// Сообщение возвращается в очередь до max раз, и только после этого
+// callback получает итоговую ошибку. После Close повторы не выполняются.
+// Нулевое значение отключает повторы.
+func (w *Publisher[T]) SetAsyncRetry(max int) error {
+	if max < 0 {
+		return ErrInvalidRetry
+	}
+
+	w.asyncRetry.Store(int32(max))
+
+	return nil
+}
+
+// Drain блокируется, пока все поставленные в очередь асинхронные сообщения
+// не будут обработаны воркерами, не закрывая Publisher.
+// Возвращает ошибку контекста, если ожидание было прервано.
+func (w *Publisher[T]) Drain(ctx context.Context) error {
+	w.pendingMutex.Lock()
+	if w.pending == 0 {
+		w.pendingMutex.Unlock()
+		return nil
+	}
+
+	drained := make(chan struct{})
+	w.drainWaiters = append(w.drainWaiters, drained)
+	w.pendingMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-drained:
+		return nil
+	}
+}
+
+// ResizeBuffer изменяет размер буфера асинхронных сообщений.
+// Новый канал устанавливается сразу: отправители, ожидающие места
+// в старом буфере, переключаются на новый. Ожидающие сообщения переносятся
+// в новый канал без потерь; если новый размер меньше их числа,
+// ResizeBuffer ждет, пока воркеры освободят место.
+// Воркеры переключаются на новый канал автоматически.
+func (w *Publisher[T]) ResizeBuffer(n int) error {
+	if w.closed.Load() {
+		return ErrClosed
+	}
+	if n < 0 {
+		return ErrInvalidBufferSize
+	}
+
+	w.resizeMutex.Lock()
+	defer w.resizeMutex.Unlock()
+
+	resized := newAsyncBuffer[T](n)
+
+	w.bufferMutex.Lock()
+	old := w.buffer.Swap(resized)
+	w.bufferMutex.Unlock()
+
+	// отправители старого буфера повторяют отправку в новый
+	close(old.resized)
+	old.senders.Wait()
+
+	// в старый канал больше никто не пишет, воркеры, ожидающие на нем, переключатся
+	close(old.ch)
+	for m := range old.ch {
+		resized.ch <- m
+	}
+
 	return nil
 }
 
 // Close корректно завершает работу Publisher.
-// Закрывает канал остановки, ожидает завершения всех воркеров.
+// Закрывает канал остановки и ожидает, пока воркеры дообработают буфер.
+// Возвращает errors.Join последних ошибок записи, полученных воркерами при дренаже.
 // Повторный вызов возвращает ErrClosed.
 func (w *Publisher[T]) Close() error {
 	if w.closed.Swap(true) {
@@ -87,33 +252,187 @@ func (w *Publisher[T]) Close() error {
 	close(w.closeCh)
 	<-w.workersFinished
 
-	return nil
+	return errors.Join(w.workerErrs...)
 }
 
 // worker — рабочая горутина, обрабатывающая асинхронные сообщения.
-// Завершается при отмене контекста или при закрытии Publisher.
-func (w *Publisher[T]) worker(ctx context.Context, wg *sync.WaitGroup) {
+// Завершается при отмене контекста или при закрытии Publisher,
+// во втором случае предварительно дообрабатывая буфер.
+func (w *Publisher[T]) worker(ctx context.Context, wg *sync.WaitGroup, id int) {
 	defer wg.Done()
 
-	var err error
-
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-w.closeCh:
+			w.drain(ctx, id)
 			return
-		case m := <-w.asyncMessagesCh:
-			err = w.write(m.Ctx, m.Message, m.Callback)
-			if err != nil {
-				zap.L().Error(err.Error())
+		case m, ok := <-w.buffer.Load().ch:
+			if !ok {
+				// буфер был заменен в ResizeBuffer, читаем из нового канала
+				continue
+			}
 
-				if m.Callback == nil {
-					continue
-				}
+			w.dequeued()
+			w.process(ctx, m, id)
+		}
+	}
+}
 
-				m.Callback(ctx, m.Message, err)
+// drain дообрабатывает оставшиеся в буфере сообщения при закрытии.
+func (w *Publisher[T]) drain(ctx context.Context, id int) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-w.buffer.Load().ch:
+			if !ok {
+				continue
 			}
+
+			w.dequeued()
+			w.process(ctx, m, id)
+		default:
+			return
+		}
+	}
+}
+
+// process записывает одно асинхронное сообщение.
+// При ошибке вызывает callback сообщения, а если Publisher уже закрывается,
+// запоминает ошибку как последнюю ошибку воркера.
+// Паника в write или callback не завершает воркер.
+func (w *Publisher[T]) process(ctx context.Context, m AsyncMessage[T], id int) {
+	defer w.donePending()
+
+	err := w.safeWrite(m)
+	if err == nil {
+		return
+	}
+
+	w.logger.Error(err.Error())
+
+	if w.retry(m) {
+		return
+	}
+
+	if w.closed.Load() {
+		w.workerErrs[id] = err
+	}
+
+	w.toFailed(m, err)
+
+	if m.Callback != nil {
+		w.safeCallback(ctx, m, err)
+	}
+}
+
+// safeWrite вызывает write, превращая панику в ошибку ErrPanic.
+func (w *Publisher[T]) safeWrite(m AsyncMessage[T]) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrPanic, r)
+		}
+	}()
+
+	return w.write(m.Ctx, m.Message, m.Callback)
+}
+
+// safeCallback вызывает callback сообщения, логируя панику.
+func (w *Publisher[T]) safeCallback(ctx context.Context, m AsyncMessage[T], err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.logger.Error(ErrPanic.Error(), zap.Any("recovered", r))
 		}
+	}()
+
+	m.Callback(ctx, m.Message, err)
+}
+
+// retry возвращает сообщение в очередь, если лимит повторов не исчерпан
+// и Publisher не закрывается. Если буфер заполнен, повтор не выполняется,
+// чтобы воркер не заблокировался на собственной очереди.
+func (w *Publisher[T]) retry(m AsyncMessage[T]) bool {
+	if w.closed.Load() || m.attempt >= int(w.asyncRetry.Load()) {
+		return false
+	}
+
+	m.attempt++
+	w.addPending()
+	w.enqueued()
+
+	if w.tryEnqueue(m) {
+		return true
+	}
+
+	w.dequeued()
+	w.donePending()
+	return false
+}
+
+// newAsyncBuffer создает буфер асинхронных сообщений размером n.
+func newAsyncBuffer[T any](n int) *asyncBuffer[T] {
+	return &asyncBuffer[T]{
+		ch:      make(chan AsyncMessage[T], n),
+		resized: make(chan struct{}),
+	}
+}
+
+// acquireBuffer возвращает текущий буфер и регистрирует в нем отправителя.
+// После попытки отправки нужно вызвать buf.senders.Done().
+// Блокировка удерживается только на время получения буфера,
+// поэтому ResizeBuffer не ждет освобождения места в заполненном буфере.
+func (w *Publisher[T]) acquireBuffer() *asyncBuffer[T] {
+	w.bufferMutex.RLock()
+	defer w.bufferMutex.RUnlock()
+
+	buf := w.buffer.Load()
+	buf.senders.Add(1)
+
+	return buf
+}
+
+// tryEnqueue помещает сообщение в буфер без блокировки.
+// Возвращает false, если буфер заполнен.
+func (w *Publisher[T]) tryEnqueue(m AsyncMessage[T]) bool {
+	for {
+		buf := w.acquireBuffer()
+
+		select {
+		case buf.ch <- m:
+			buf.senders.Done()
+			return true
+		case <-buf.resized:
+			// буфер заменен, повторяем попытку с новым
+			buf.senders.Done()
+		default:
+			buf.senders.Done()
+			return false
+		}
+	}
+}
+
+// addPending учитывает новое сообщение, ожидающее обработки.
+func (w *Publisher[T]) addPending() {
+	w.pendingMutex.Lock()
+	w.pending++
+	w.pendingMutex.Unlock()
+}
+
+// donePending отмечает сообщение обработанным и, если очередь опустела,
+// освобождает всех ожидающих в Drain.
+func (w *Publisher[T]) donePending() {
+	w.pendingMutex.Lock()
+	defer w.pendingMutex.Unlock()
+
+	w.pending--
+	if w.pending > 0 {
+		return
+	}
+
+	for _, drained := range w.drainWaiters {
+		close(drained)
 	}
+	w.drainWaiters = nil
 }
@@ -3,10 +3,15 @@ package publisher
 import (
 	"context"
 	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestPublisher_SendSync(t *testing.T) {
@@ -18,9 +23,10 @@ func TestPublisher_SendSync(t *testing.T) {
 		return nil
 	}
 
-	p := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	assert.NoError(t, err)
 
-	err := p.SendSync(t.Context(), 1)
+	err = p.SendSync(t.Context(), 1)
 	assert.NoError(t, err)
 	assert.True(t, called)
 }
@@ -31,10 +37,11 @@ func TestPublisher_SendSync_WaitsForWrite(t *testing.T) {
 		return nil
 	}
 
-	p := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	assert.NoError(t, err)
 
 	start := time.Now()
-	err := p.SendSync(t.Context(), 1)
+	err = p.SendSync(t.Context(), 1)
 	elapsed := time.Since(start)
 
 	assert.NoError(t, err)
@@ -48,9 +55,10 @@ func TestPublisher_SendAsync(t *testing.T) {
 		return nil
 	}
 
-	p := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	assert.NoError(t, err)
 
-	err := p.SendAsync(t.Context(), 1, func(ctx context.Context, v int, err error) {
+	err = p.SendAsync(t.Context(), 1, func(ctx context.Context, v int, err error) {
 		assert.NoError(t, err)
 		assert.Equal(t, 1, v)
 		close(done)
@@ -78,10 +86,11 @@ func TestPublisher_SendAsync_DoesNotWaitForWrite(t *testing.T) {
 		return nil
 	}
 
-	p := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	assert.NoError(t, err)
 
 	start := time.Now()
-	err := p.SendAsync(t.Context(), 1, nil)
+	err = p.SendAsync(t.Context(), 1, nil)
 	elapsed := time.Since(start)
 
 	assert.NoError(t, err)
@@ -101,9 +110,10 @@ func TestPublisher_SendAsync_CallbackReceivesError(t *testing.T) {
 		return expectedErr
 	}
 
-	p := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	assert.NoError(t, err)
 
-	err := p.SendAsync(t.Context(), 1, func(ctx context.Context, v int, err error) {
+	err = p.SendAsync(t.Context(), 1, func(ctx context.Context, v int, err error) {
 		assert.ErrorIs(t, err, expectedErr)
 		assert.Equal(t, 1, v)
 		close(done)
@@ -119,3 +129,703 @@ func TestPublisher_SendAsync_CallbackReceivesError(t *testing.T) {
 
 	assert.NoError(t, p.Close())
 }
+
+func TestPublisher_ResizeBuffer(t *testing.T) {
+	const initialSize = 2
+	const resizedSize = 10
+
+	release := make(chan struct{})
+	var processed atomic.Int32
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		<-release
+		processed.Add(1)
+		return nil
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, initialSize)
+	assert.NoError(t, err)
+
+	// один воркер забирает первое сообщение и блокируется, буфер заполняется
+	sent := 0
+	for range initialSize + 1 {
+		assert.NoError(t, p.SendAsync(t.Context(), sent, nil))
+		sent++
+	}
+
+	assert.ErrorIs(t, p.ResizeBuffer(-1), ErrInvalidBufferSize)
+	assert.NoError(t, p.ResizeBuffer(resizedSize))
+
+	sendDone := make(chan struct{})
+	go func() {
+		defer close(sendDone)
+		for range resizedSize - initialSize {
+			assert.NoError(t, p.SendAsync(t.Context(), sent, nil))
+			sent++
+		}
+	}()
+
+	select {
+	case <-sendDone:
+	case <-time.After(time.Second):
+		assert.Fail(t, "SendAsync заблокировался после увеличения буфера")
+	}
+
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		return processed.Load() == int32(resizedSize+1)
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, p.Close())
+}
+
+func TestPublisher_ResizeBufferWithBlockedSenders(t *testing.T) {
+	const blockedSenders = 3
+
+	release := make(chan struct{})
+	var processed atomic.Int32
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		<-release
+		processed.Add(1)
+		return nil
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	assert.NoError(t, err)
+
+	// воркер забирает первое сообщение и блокируется, второе заполняет буфер
+	assert.NoError(t, p.SendAsync(t.Context(), 0, nil))
+	assert.Eventually(t, func() bool {
+		return p.TrySendAsync(t.Context(), 1, nil) == nil
+	}, time.Second, time.Millisecond)
+
+	var senders sync.WaitGroup
+	for i := range blockedSenders {
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			assert.NoError(t, p.SendAsync(t.Context(), i+2, nil))
+		}()
+	}
+
+	sendersDone := make(chan struct{})
+	go func() {
+		senders.Wait()
+		close(sendersDone)
+	}()
+
+	select {
+	case <-sendersDone:
+		t.Fatal("отправители не должны были пройти до увеличения буфера")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	resized := make(chan error, 1)
+	go func() {
+		resized <- p.ResizeBuffer(blockedSenders + 1)
+	}()
+
+	select {
+	case err := <-resized:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("ResizeBuffer ждал освобождения заполненного буфера")
+	}
+
+	select {
+	case <-sendersDone:
+	case <-time.After(time.Second):
+		t.Fatal("отправители не переключились на новый буфер")
+	}
+
+	close(release)
+
+	assert.NoError(t, p.Close())
+	assert.Equal(t, int32(blockedSenders+2), processed.Load())
+}
+
+func TestPublisher_Drain(t *testing.T) {
+	const messageCount = 50
+
+	var callbacks atomic.Int32
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		time.Sleep(time.Millisecond)
+		callback(ctx, v, nil)
+		return nil
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 4, messageCount)
+	assert.NoError(t, err)
+
+	for i := range messageCount {
+		assert.NoError(t, p.SendAsync(t.Context(), i, func(ctx context.Context, v int, err error) {
+			callbacks.Add(1)
+		}))
+	}
+
+	assert.NoError(t, p.Drain(t.Context()))
+	assert.Equal(t, int32(messageCount), callbacks.Load())
+
+	// пустая очередь не блокирует Drain
+	assert.NoError(t, p.Drain(t.Context()))
+
+	assert.NoError(t, p.Close())
+}
+
+func TestPublisher_Drain_ContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		<-release
+		return nil
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, p.SendAsync(t.Context(), 1, nil))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, p.Drain(ctx), context.DeadlineExceeded)
+}
+
+func TestPublisher_Close_ReturnsDrainErrors(t *testing.T) {
+	errDrain := errors.New("drain failed")
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		if v == 0 {
+			close(started)
+			<-release
+			return nil
+		}
+		return errDrain
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 4)
+	assert.NoError(t, err)
+
+	for i := range 3 {
+		assert.NoError(t, p.SendAsync(t.Context(), i, nil))
+	}
+	<-started
+
+	closed := make(chan error)
+	go func() {
+		closed <- p.Close()
+	}()
+
+	assert.Eventually(t, p.closed.Load, time.Second, time.Millisecond)
+	close(release)
+
+	select {
+	case err := <-closed:
+		assert.ErrorIs(t, err, errDrain)
+	case <-time.After(time.Second):
+		t.Fatal("Close не завершился")
+	}
+}
+
+func TestPublisher_TrySendAsync_CountsDropped(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 2)
+	assert.NoError(t, err)
+
+	// первое сообщение занимает воркер, следующие два заполняют буфер
+	assert.NoError(t, p.TrySendAsync(t.Context(), 0, nil))
+	<-started
+	assert.NoError(t, p.TrySendAsync(t.Context(), 1, nil))
+	assert.NoError(t, p.TrySendAsync(t.Context(), 2, nil))
+
+	const failed = 3
+	for i := range failed {
+		assert.ErrorIs(t, p.TrySendAsync(t.Context(), 3+i, nil), ErrBufferFull)
+	}
+	assert.Equal(t, uint64(failed), p.Stats().Dropped)
+
+	close(release)
+	assert.NoError(t, p.Drain(t.Context()))
+	assert.NoError(t, p.Close())
+}
+
+func TestPublisher_SetAsyncRetry(t *testing.T) {
+	var attempts atomic.Int32
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		if attempts.Add(1) <= 2 {
+			return errors.New("write failed")
+		}
+		callback(ctx, v, nil)
+		return nil
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetAsyncRetry(2))
+	assert.ErrorIs(t, p.SetAsyncRetry(-1), ErrInvalidRetry)
+
+	errs := make(chan error, 4)
+	assert.NoError(t, p.SendAsync(t.Context(), 1, func(ctx context.Context, v int, err error) {
+		errs <- err
+	}))
+
+	assert.NoError(t, p.Drain(t.Context()))
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.Len(t, errs, 1)
+	assert.NoError(t, <-errs)
+
+	assert.NoError(t, p.Close())
+}
+
+func TestPublisher_SetAsyncRetry_Exhausted(t *testing.T) {
+	errWrite := errors.New("write failed")
+	var attempts atomic.Int32
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		attempts.Add(1)
+		return errWrite
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetAsyncRetry(2))
+
+	errs := make(chan error, 4)
+	assert.NoError(t, p.SendAsync(t.Context(), 1, func(ctx context.Context, v int, err error) {
+		errs <- err
+	}))
+
+	assert.NoError(t, p.Drain(t.Context()))
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, <-errs, errWrite)
+
+	assert.NoError(t, p.Close())
+}
+
+func TestPublisher_RecoversWritePanic(t *testing.T) {
+	var written atomic.Int32
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		if v == 1 {
+			panic("write failed")
+		}
+		written.Add(1)
+		return nil
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 4)
+	assert.NoError(t, err)
+
+	errs := make(chan error, 1)
+	err = p.SendAsync(t.Context(), 1, func(ctx context.Context, v int, err error) {
+		errs <- err
+		panic("callback failed")
+	})
+	assert.NoError(t, err)
+
+	for i := 2; i <= 3; i++ {
+		assert.NoError(t, p.SendAsync(t.Context(), i, nil))
+	}
+
+	assert.NoError(t, p.Drain(t.Context()))
+	assert.NoError(t, p.Close())
+
+	assert.ErrorIs(t, <-errs, ErrPanic)
+	assert.Equal(t, int32(2), written.Load(), "воркер должен обработать сообщения после паники")
+}
+
+func TestPublisher_WithLogger(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	globalCore, globalLogs := observer.New(zap.ErrorLevel)
+	defer zap.ReplaceGlobals(zap.New(globalCore))()
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		return errors.New("write failed")
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 1, WithLogger[int](zap.New(core)))
+	assert.NoError(t, err)
+
+	assert.Error(t, p.SendSync(t.Context(), 1))
+	assert.Equal(t, 1, logs.FilterMessage("write failed").Len())
+	assert.Equal(t, 0, globalLogs.Len())
+}
+
+func TestKeyedPublisher_PreservesKeyOrder(t *testing.T) {
+	type message struct {
+		key string
+		seq int
+	}
+
+	var (
+		mu  sync.Mutex
+		got = make(map[string][]int)
+	)
+
+	writeFn := func(ctx context.Context, m message, callback Callback[message]) error {
+		// задержка зависит от сообщения, чтобы перемешать обработку разных ключей
+		time.Sleep(time.Duration(m.seq%3) * time.Millisecond)
+
+		mu.Lock()
+		got[m.key] = append(got[m.key], m.seq)
+		mu.Unlock()
+		return nil
+	}
+
+	p, err := NewKeyedPublisher[message](t.Context(), writeFn, func(m message) string {
+		return m.key
+	}, 4, 8)
+	assert.NoError(t, err)
+
+	keys := []string{"a", "b", "c", "d", "e"}
+	const perKey = 50
+	for seq := range perKey {
+		for _, key := range keys {
+			assert.NoError(t, p.SendAsync(t.Context(), message{key: key, seq: seq}, nil))
+		}
+	}
+
+	assert.NoError(t, p.Close())
+	assert.ErrorIs(t, p.Close(), ErrClosed)
+	assert.ErrorIs(t, p.SendAsync(t.Context(), message{}, nil), ErrClosed)
+
+	for _, key := range keys {
+		assert.Len(t, got[key], perKey)
+		assert.IsIncreasing(t, got[key], "сообщения ключа %q записаны не по порядку", key)
+	}
+}
+
+func TestKeyedPublisher_CallbackReceivesError(t *testing.T) {
+	errWrite := errors.New("write failed")
+
+	p, err := NewKeyedPublisher[int](t.Context(), func(ctx context.Context, v int, callback Callback[int]) error {
+		return errWrite
+	}, strconv.Itoa, 2, 1)
+	assert.NoError(t, err)
+
+	errs := make(chan error, 1)
+	assert.NoError(t, p.SendAsync(t.Context(), 1, func(ctx context.Context, v int, err error) {
+		errs <- err
+	}))
+	assert.NoError(t, p.Close())
+
+	assert.ErrorIs(t, <-errs, errWrite)
+}
+
+func TestKeyedPublisher_CancelUnblocksSendAsync(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+
+	release := make(chan struct{})
+	writing := make(chan struct{}, 1)
+	var written atomic.Int32
+
+	p, err := NewKeyedPublisher[int](ctx, func(ctx context.Context, v int, callback Callback[int]) error {
+		writing <- struct{}{}
+		<-release
+		written.Add(1)
+		return nil
+	}, strconv.Itoa, 1, 1)
+	assert.NoError(t, err)
+
+	// первое сообщение занимает воркер, второе заполняет очередь
+	assert.NoError(t, p.SendAsync(t.Context(), 1, nil))
+	<-writing
+	assert.NoError(t, p.SendAsync(t.Context(), 2, nil))
+
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- p.SendAsync(t.Context(), 3, nil)
+	}()
+
+	select {
+	case err := <-sendErr:
+		t.Fatalf("SendAsync не должен был вернуться до отмены ctx: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+
+	select {
+	case err := <-sendErr:
+		assert.ErrorIs(t, err, ErrClosed)
+	case <-time.After(time.Second):
+		t.Fatal("SendAsync остался заблокирован после отмены ctx")
+	}
+
+	closeErr := make(chan error, 1)
+	go func() {
+		closeErr <- p.Close()
+	}()
+
+	close(release)
+
+	select {
+	case err := <-closeErr:
+		assert.ErrorIs(t, err, ErrClosed)
+	case <-time.After(time.Second):
+		t.Fatal("Close заблокирован после отмены ctx")
+	}
+
+	// сообщение из очереди дообработано при остановке
+	assert.Equal(t, int32(2), written.Load())
+}
+
+func TestNewKeyedPublisher_InvalidArgs(t *testing.T) {
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error { return nil }
+
+	_, err := NewKeyedPublisher[int](t.Context(), writeFn, strconv.Itoa, 0, 1)
+	assert.ErrorIs(t, err, ErrInvalidWorkerCount)
+
+	_, err = NewKeyedPublisher[int](t.Context(), writeFn, nil, 1, 1)
+	assert.ErrorIs(t, err, ErrInvalidKeyFn)
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+
+	recording := func(name string) Middleware[int] {
+		return func(next WriteFn[int]) WriteFn[int] {
+			return func(ctx context.Context, v int, callback Callback[int]) error {
+				calls = append(calls, name+":before")
+				err := next(ctx, v, callback)
+				calls = append(calls, name+":after")
+				return err
+			}
+		}
+	}
+
+	base := func(ctx context.Context, v int, callback Callback[int]) error {
+		calls = append(calls, "base")
+		return nil
+	}
+
+	write := Chain[int](base, recording("first"), recording("second"))
+	assert.NoError(t, write(t.Context(), 1, nil))
+
+	assert.Equal(t, []string{
+		"first:before",
+		"second:before",
+		"base",
+		"second:after",
+		"first:after",
+	}, calls)
+}
+
+func TestTimingMiddleware(t *testing.T) {
+	expectedErr := errors.New("write failed")
+
+	var observed time.Duration
+	var observedErr error
+
+	base := func(ctx context.Context, v int, callback Callback[int]) error {
+		time.Sleep(20 * time.Millisecond)
+		return expectedErr
+	}
+
+	write := Chain[int](base, TimingMiddleware[int](func(duration time.Duration, err error) {
+		observed = duration
+		observedErr = err
+	}))
+
+	assert.ErrorIs(t, write(t.Context(), 1, nil), expectedErr)
+	assert.GreaterOrEqual(t, observed, 20*time.Millisecond)
+	assert.ErrorIs(t, observedErr, expectedErr)
+}
+
+func TestPublisher_HighWaterMark(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 10)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, p.SetHighWaterMark(0, nil, nil), ErrInvalidHighWaterMark)
+	assert.ErrorIs(t, p.SetHighWaterMark(1.5, nil, nil), ErrInvalidHighWaterMark)
+
+	high := make(chan struct{}, 1)
+	normal := make(chan struct{}, 1)
+	assert.NoError(t, p.SetHighWaterMark(0.5, func() {
+		high <- struct{}{}
+	}, func() {
+		normal <- struct{}{}
+	}))
+
+	// первое сообщение забирает воркер и блокируется на записи
+	assert.NoError(t, p.SendAsync(t.Context(), 0, nil))
+	<-started
+
+	for i := 1; i < 5; i++ {
+		assert.NoError(t, p.SendAsync(t.Context(), i, nil))
+	}
+	select {
+	case <-high:
+		t.Fatal("onHigh called below the mark")
+	default:
+	}
+
+	assert.NoError(t, p.SendAsync(t.Context(), 5, nil))
+	select {
+	case <-high:
+	case <-time.After(time.Second):
+		t.Fatal("onHigh was not called after crossing the mark")
+	}
+
+	close(release)
+	select {
+	case <-normal:
+	case <-time.After(time.Second):
+		t.Fatal("onNormal was not called after the buffer drained")
+	}
+
+	assert.NoError(t, p.Drain(t.Context()))
+	assert.NoError(t, p.Close())
+}
+
+func TestPublisher_HighWaterMarkCallbacksOutsideLock(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 2)
+	assert.NoError(t, err)
+
+	// обработчик может обращаться к Publisher, например расширить буфер
+	resized := make(chan error, 1)
+	assert.NoError(t, p.SetHighWaterMark(1, func() {
+		resized <- p.ResizeBuffer(10)
+	}, nil))
+
+	assert.NoError(t, p.SendAsync(t.Context(), 0, nil))
+	<-started
+
+	sent := make(chan error, 1)
+	go func() {
+		assert.NoError(t, p.SendAsync(t.Context(), 1, nil))
+		sent <- p.SendAsync(t.Context(), 2, nil)
+	}()
+
+	select {
+	case err := <-resized:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("onHigh заблокирован внутри Publisher")
+	}
+	assert.NoError(t, <-sent)
+
+	close(release)
+	assert.NoError(t, p.Drain(t.Context()))
+	assert.NoError(t, p.Close())
+}
+
+func TestPublisher_HighWaterMarkUnbuffered(t *testing.T) {
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		return nil
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 0)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, p.SetHighWaterMark(0.5, nil, nil), ErrUnbufferedHighWaterMark)
+	assert.NoError(t, p.Close())
+
+	// после уменьшения буфера до 0 порог перестает действовать
+	p, err = NewPublisher[int](t.Context(), writeFn, 1, 4)
+	assert.NoError(t, err)
+
+	var high, normal atomic.Int32
+	assert.NoError(t, p.SetHighWaterMark(0.5, func() {
+		high.Add(1)
+	}, func() {
+		normal.Add(1)
+	}))
+	assert.NoError(t, p.ResizeBuffer(0))
+
+	for i := range 10 {
+		assert.NoError(t, p.SendAsync(t.Context(), i, nil))
+	}
+	assert.NoError(t, p.Drain(t.Context()))
+	assert.NoError(t, p.Close())
+
+	assert.Equal(t, int32(0), high.Load())
+	assert.Equal(t, int32(0), normal.Load())
+}
+
+func TestPublisher_FailedMessages(t *testing.T) {
+	errWrite := errors.New("write failed")
+	var attempts atomic.Int32
+
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error {
+		attempts.Add(1)
+		return errWrite
+	}
+
+	p, err := NewPublisher[int](t.Context(), writeFn, 1, 4)
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetAsyncRetry(2))
+
+	failed := p.FailedMessages()
+	assert.Equal(t, failed, p.FailedMessages())
+
+	errs := make(chan error, 1)
+	assert.NoError(t, p.SendAsync(t.Context(), 7, func(ctx context.Context, v int, err error) {
+		errs <- err
+	}))
+
+	select {
+	case m := <-failed:
+		assert.Equal(t, 7, m.Message)
+		assert.ErrorIs(t, m.Err, errWrite)
+	case <-time.After(time.Second):
+		t.Fatal("failed message was not routed to FailedMessages")
+	}
+
+	assert.NoError(t, p.Drain(t.Context()))
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.ErrorIs(t, <-errs, errWrite)
+
+	assert.NoError(t, p.Close())
+}
+
+func TestNewPublisher_InvalidArgs(t *testing.T) {
+	writeFn := func(ctx context.Context, v int, callback Callback[int]) error { return nil }
+
+	_, err := NewPublisher[int](t.Context(), writeFn, 0, 1)
+	assert.ErrorIs(t, err, ErrInvalidWorkerCount)
+
+	_, err = NewPublisher[int](t.Context(), writeFn, 1, -1)
+	assert.ErrorIs(t, err, ErrInvalidBufferSize)
+}
@@ -0,0 +1,13 @@
+package publisher
+
+// Stats снимок счетчиков Publisher.
+type Stats struct {
+	Dropped uint64 // Сообщения, отброшенные TrySendAsync из-за заполненного буфера
+}
+
+// Stats возвращает снимок счетчиков Publisher.
+func (w *Publisher[T]) Stats() Stats {
+	return Stats{
+		Dropped: w.dropped.Load(),
+	}
+}
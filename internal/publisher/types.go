@@ -4,3 +4,4 @@ import "context"
 
 type Callback[T any] = func(ctx context.Context, message T, err error)
 type WriteFn[T any] = func(ctx context.Context, message T, callback Callback[T]) error
+type Middleware[T any] = func(next WriteFn[T]) WriteFn[T]
@@ -0,0 +1,68 @@
+package sender
+
+import (
+	"ay-events-generator/internal/context_merge"
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/producer_batcher"
+	"ay-events-generator/internal/publisher"
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// SetBatchTime задает максимальное время накопления асинхронных событий.
+func (s *KafkaSender) SetBatchTime(d time.Duration) {
+	s.batcher.SetFlushTime(d)
+}
+
+// SetBatchEventCount задает количество асинхронных событий,
+// при накоплении которого батч отправляется не дожидаясь таймера.
+func (s *KafkaSender) SetBatchEventCount(count uint) {
+	s.batcher.SetFlushSize(count)
+}
+
+// SendAsync помещает событие в батч. Батч записывается одним вызовом
+// WriteMessages при достижении SetBatchEventCount или по истечении SetBatchTime.
+// Результат записи передается в callback.
+func (s *KafkaSender) SendAsync(ctx context.Context, message event.PageViewEvent, callback publisher.Callback[event.PageViewEvent]) error {
+	return s.batcher.Push(ctx, message, callback)
+}
+
+// flushBatch сериализует батч и записывает его одним вызовом WriteMessages.
+// События, которые не удалось сериализовать, получают ошибку в callback
+// и не попадают в запись.
+func (s *KafkaSender) flushBatch(messages []producer_batcher.Message[event.PageViewEvent]) {
+	valid := make([]producer_batcher.Message[event.PageViewEvent], 0, len(messages))
+	kafkaMessages := make([]kafka.Message, 0, len(messages))
+	ctxs := make([]context.Context, 0, len(messages))
+
+	for _, m := range messages {
+		msg, err := s.message(m.Ctx, m.Data)
+		if err != nil {
+			if m.Callback != nil {
+				m.Callback(m.Ctx, m.Data, err)
+			}
+			continue
+		}
+
+		valid = append(valid, m)
+		kafkaMessages = append(kafkaMessages, msg)
+		ctxs = append(ctxs, m.Ctx)
+	}
+
+	if len(kafkaMessages) == 0 {
+		return
+	}
+
+	ctx, cancel := context_merge.Merge(ctxs...)
+	defer cancel()
+
+	err := s.writeMessages(ctx, kafkaMessages...)
+
+	for _, m := range valid {
+		if m.Callback != nil {
+			m.Callback(m.Ctx, m.Data, err)
+		}
+	}
+}
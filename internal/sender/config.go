@@ -0,0 +1,77 @@
+package sender
+
+import (
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig параметры подключения KafkaSender к Kafka.
+type KafkaConfig struct {
+	Brokers []string // Адреса брокеров host:port
+	Topic   string   // Топик для записи событий
+
+	// WriteTimeout ограничивает запись в брокер, ноль — значение kafka-go по умолчанию
+	WriteTimeout time.Duration
+	// RequiredAcks количество подтверждений записи, по умолчанию kafka.RequireNone
+	RequiredAcks kafka.RequiredAcks
+	// Balancer распределяет сообщения по партициям, nil — kafka.RoundRobin
+	Balancer kafka.Balancer
+}
+
+// WriterFactory создает KafkaWriter по конфигурации.
+// Позволяет подменить writer в тестах.
+type WriterFactory = func(cfg KafkaConfig) KafkaWriter
+
+// Validate проверяет конфигурацию.
+func (c KafkaConfig) Validate() error {
+	if len(c.Brokers) == 0 {
+		return ErrNoBrokers
+	}
+	for _, broker := range c.Brokers {
+		if broker == "" {
+			return ErrNoBrokers
+		}
+	}
+
+	if c.Topic == "" {
+		return ErrEmptyTopic
+	}
+
+	if c.WriteTimeout < 0 {
+		return ErrInvalidWriteTimeout
+	}
+
+	switch c.RequiredAcks {
+	case kafka.RequireNone, kafka.RequireOne, kafka.RequireAll:
+	default:
+		return ErrInvalidRequiredAcks
+	}
+
+	return nil
+}
+
+// NewKafkaWriter создает *kafka.Writer по конфигурации.
+func NewKafkaWriter(cfg KafkaConfig) KafkaWriter {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		WriteTimeout: cfg.WriteTimeout,
+		RequiredAcks: cfg.RequiredAcks,
+		Balancer:     cfg.Balancer,
+	}
+}
+
+// NewKafkaSenderFromConfig проверяет конфигурацию и создает отправителя
+// поверх writer, полученного от factory. nil factory означает NewKafkaWriter.
+func NewKafkaSenderFromConfig(cfg KafkaConfig, factory WriterFactory) (*KafkaSender, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if factory == nil {
+		factory = NewKafkaWriter
+	}
+
+	return NewKafkaSender(factory(cfg)), nil
+}
@@ -0,0 +1,8 @@
+package sender
+
+import "time"
+
+const (
+	defaultBatchTime       = time.Second
+	defaultBatchEventCount = 100
+)
@@ -0,0 +1,14 @@
+package sender
+
+import "errors"
+
+var (
+	ErrClosed          = errors.New("sender closed")
+	ErrMessageTooLarge = errors.New("message too large")
+	ErrInvalidEvent    = errors.New("invalid event")
+
+	ErrNoBrokers           = errors.New("no kafka brokers")
+	ErrEmptyTopic          = errors.New("empty kafka topic")
+	ErrInvalidWriteTimeout = errors.New("invalid write timeout")
+	ErrInvalidRequiredAcks = errors.New("invalid required acks")
+)
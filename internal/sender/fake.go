@@ -0,0 +1,90 @@
+package sender
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/publisher"
+	"context"
+	"errors"
+	"sync"
+)
+
+// FakeSender in-memory реализация Sender для тестов.
+// Запоминает отправленные события и может возвращать заданную ошибку.
+// SendAsync вызывает callback синхронно, поэтому поведение детерминировано.
+type FakeSender struct {
+	m      sync.Mutex
+	sent   []event.PageViewEvent
+	err    error
+	closed bool
+}
+
+var _ Sender = (*FakeSender)(nil)
+
+// NewFakeSender создает пустой FakeSender.
+func NewFakeSender() *FakeSender {
+	return &FakeSender{}
+}
+
+// SetError задает ошибку, возвращаемую всеми последующими отправками.
+// Событие, завершившееся ошибкой, не записывается. nil отключает ошибку.
+func (s *FakeSender) SetError(err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.err = err
+}
+
+// Sent возвращает копию успешно отправленных событий в порядке отправки.
+func (s *FakeSender) Sent() []event.PageViewEvent {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	sent := make([]event.PageViewEvent, len(s.sent))
+	copy(sent, s.sent)
+	return sent
+}
+
+// SendSync записывает событие или возвращает заданную ошибку.
+func (s *FakeSender) SendSync(ctx context.Context, message event.PageViewEvent) error {
+	return s.record(message)
+}
+
+// SendAsync записывает событие и передает результат в callback.
+// Возвращает ошибку только для закрытого отправителя.
+func (s *FakeSender) SendAsync(ctx context.Context, message event.PageViewEvent, callback publisher.Callback[event.PageViewEvent]) error {
+	err := s.record(message)
+	if errors.Is(err, ErrClosed) {
+		return err
+	}
+
+	if callback != nil {
+		callback(ctx, message, err)
+	}
+
+	return nil
+}
+
+// Close закрывает отправитель, последующие отправки возвращают ErrClosed.
+func (s *FakeSender) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.closed = true
+	return nil
+}
+
+// record запоминает событие, если отправитель открыт и ошибка не задана.
+func (s *FakeSender) record(message event.PageViewEvent) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.err != nil {
+		return s.err
+	}
+
+	s.sent = append(s.sent, message)
+	return nil
+}
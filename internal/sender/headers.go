@@ -0,0 +1,32 @@
+package sender
+
+import (
+	"ay-events-generator/internal/event"
+	"slices"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers преобразует заголовки события в заголовки Kafka-сообщения.
+// Заголовки упорядочиваются по ключу, чтобы сообщения были детерминированы.
+func Headers(message event.PageViewEvent) []kafka.Header {
+	if len(message.Headers) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(message.Headers))
+	for key := range message.Headers {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	headers := make([]kafka.Header, 0, len(keys))
+	for _, key := range keys {
+		headers = append(headers, kafka.Header{
+			Key:   key,
+			Value: []byte(message.Headers[key]),
+		})
+	}
+
+	return headers
+}
@@ -0,0 +1,270 @@
+package sender
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/producer_batcher"
+	"ay-events-generator/internal/publisher"
+	"ay-events-generator/internal/serializer"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// KafkaSender сериализует события и записывает их в Kafka через KafkaWriter.
+type KafkaSender struct {
+	writer       KafkaWriter
+	writerMutex  sync.RWMutex
+	useEventTime atomic.Bool
+	validate     atomic.Bool
+	keyFn        atomic.Pointer[KeyFn]
+	serializer   atomic.Pointer[Serializer]
+	syncTimeout  atomic.Int64
+	maxBytes     atomic.Int64
+	batcher      *producer_batcher.Batcher[event.PageViewEvent]
+}
+
+var _ Sender = (*KafkaSender)(nil)
+
+// NewKafkaSender создает отправителя поверх переданного writer.
+// По умолчанию ключом сообщения служит PartitionKey события.
+// Асинхронные события батчатся по количеству и по времени.
+func NewKafkaSender(writer KafkaWriter) *KafkaSender {
+	s := &KafkaSender{
+		writer: writer,
+	}
+
+	s.SetKeyFn(defaultKeyFn)
+
+	s.batcher, _ = producer_batcher.NewBatcher(s.flushBatch)
+	s.batcher.SetFlushSize(defaultBatchEventCount)
+	s.batcher.SetFlushTime(defaultBatchTime)
+	s.batcher.SetMode(producer_batcher.HybridMode)
+
+	return s
+}
+
+// Writer возвращает текущий writer.
+func (s *KafkaSender) Writer() KafkaWriter {
+	s.writerMutex.RLock()
+	defer s.writerMutex.RUnlock()
+
+	return s.writer
+}
+
+// SetWriter заменяет writer. Дожидается завершения записей, начатых
+// через старый writer, после чего закрывает его и возвращает ошибку закрытия.
+// Последующие записи идут в новый writer.
+func (s *KafkaSender) SetWriter(writer KafkaWriter) error {
+	s.writerMutex.Lock()
+	old := s.writer
+	s.writer = writer
+	s.writerMutex.Unlock()
+
+	if old == nil || old == writer {
+		return nil
+	}
+
+	return old.Close()
+}
+
+// SetKeyFn задает функцию извлечения ключа сообщения.
+// Ключ должен соответствовать выбранной стратегии партиционирования.
+// nil восстанавливает функцию по умолчанию (PartitionKey события).
+func (s *KafkaSender) SetKeyFn(fn KeyFn) {
+	if fn == nil {
+		fn = defaultKeyFn
+	}
+	s.keyFn.Store(&fn)
+}
+
+// SetSerializer задает сериализатор значения сообщения.
+// Тип содержимого передается в заголовке serializer.ContentTypeHeader.
+// nil восстанавливает сериализацию по умолчанию через event.PageViewEvent.Bytes.
+func (s *KafkaSender) SetSerializer(ser Serializer) {
+	if ser == nil {
+		s.serializer.Store(nil)
+		return
+	}
+	s.serializer.Store(&ser)
+}
+
+// SetValidateBeforeSend включает проверку event.PageViewEvent.Validate перед записью.
+// Недействительные события не записываются и завершаются ошибкой ErrInvalidEvent,
+// оборачивающей причину.
+func (s *KafkaSender) SetValidateBeforeSend(value bool) {
+	s.validate.Store(value)
+}
+
+// SetUseEventTime включает использование Timestamp события в качестве
+// времени Kafka-сообщения. По умолчанию время назначает брокер.
+func (s *KafkaSender) SetUseEventTime(value bool) {
+	s.useEventTime.Store(value)
+}
+
+// SetSyncTimeout задает ограничение времени SendSync для контекстов без дедлайна.
+// Нулевое значение отключает ограничение.
+func (s *KafkaSender) SetSyncTimeout(d time.Duration) {
+	s.syncTimeout.Store(int64(d))
+}
+
+// SetMaxMessageBytes задает максимальный размер сериализованного события.
+// Более крупные события не записываются, а завершаются ErrMessageTooLarge.
+// Нулевое значение отключает проверку.
+func (s *KafkaSender) SetMaxMessageBytes(n int) {
+	s.maxBytes.Store(int64(max(n, 0)))
+}
+
+// SendSync синхронно записывает событие в Kafka.
+// Если у контекста нет дедлайна и задан SetSyncTimeout, запись ограничивается им.
+func (s *KafkaSender) SendSync(ctx context.Context, message event.PageViewEvent) error {
+	ctx, cancel := s.syncContext(ctx)
+	defer cancel()
+
+	return s.write(ctx, message)
+}
+
+// SendTombstone синхронно записывает надгробие: сообщение с ключом key
+// и пустым значением, удаляющее ключ из компактизируемого топика.
+func (s *KafkaSender) SendTombstone(ctx context.Context, key string) error {
+	ctx, cancel := s.syncContext(ctx)
+	defer cancel()
+
+	return s.writeMessages(ctx, kafka.Message{
+		Key:   []byte(key),
+		Value: nil,
+	})
+}
+
+// syncContext ограничивает синхронную запись SetSyncTimeout,
+// если у контекста нет собственного дедлайна.
+func (s *KafkaSender) syncContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(s.syncTimeout.Load())
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// WriteFn записывает событие и вызывает callback при успехе.
+// Совместима с publisher.WriteFn: при ошибке callback вызывает воркер Publisher.
+func (s *KafkaSender) WriteFn(ctx context.Context, message event.PageViewEvent, callback publisher.Callback[event.PageViewEvent]) error {
+	if err := s.write(ctx, message); err != nil {
+		return err
+	}
+
+	if callback != nil {
+		callback(ctx, message, nil)
+	}
+
+	return nil
+}
+
+// Close отправляет накопленные асинхронные события и закрывает нижележащий writer.
+func (s *KafkaSender) Close() error {
+	s.batcher.Close()
+	return s.Writer().Close()
+}
+
+// write сериализует событие в kafka.Message и записывает его.
+func (s *KafkaSender) write(ctx context.Context, message event.PageViewEvent) error {
+	msg, err := s.message(ctx, message)
+	if err != nil {
+		return err
+	}
+
+	return s.writeMessages(ctx, msg)
+}
+
+// message сериализует событие в kafka.Message.
+// Для надгробия (event.IsTombstone) возвращается сообщение с ключом и пустым значением.
+func (s *KafkaSender) message(ctx context.Context, message event.PageViewEvent) (kafka.Message, error) {
+	if event.IsTombstone(ctx) {
+		return kafka.Message{
+			Key:   (*s.keyFn.Load())(message),
+			Value: nil,
+		}, nil
+	}
+
+	if s.validate.Load() {
+		if err := message.Validate(); err != nil {
+			err = fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+			zap.L().Error(err.Error())
+			return kafka.Message{}, err
+		}
+	}
+
+	b, contentType, err := s.marshal(message)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	if limit := s.maxBytes.Load(); limit > 0 && int64(len(b)) > limit {
+		zap.L().Error(ErrMessageTooLarge.Error(), zap.Int("size", len(b)), zap.Int64("limit", limit))
+		return kafka.Message{}, ErrMessageTooLarge
+	}
+
+	msg := kafka.Message{
+		Key:     (*s.keyFn.Load())(message),
+		Value:   b,
+		Headers: Headers(message),
+	}
+
+	if contentType != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{
+			Key:   serializer.ContentTypeHeader,
+			Value: []byte(contentType),
+		})
+	}
+
+	if s.useEventTime.Load() {
+		msg.Time = message.Timestamp
+	}
+
+	return msg, nil
+}
+
+// marshal сериализует событие заданным сериализатором и возвращает
+// тип содержимого. Без сериализатора используется event.PageViewEvent.Bytes.
+func (s *KafkaSender) marshal(message event.PageViewEvent) ([]byte, string, error) {
+	ser := s.serializer.Load()
+	if ser == nil {
+		b, err := message.Bytes()
+		return b, "", err
+	}
+
+	b, err := (*ser).Marshal(message)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return nil, "", err
+	}
+
+	return b, (*ser).ContentType(), nil
+}
+
+// writeMessages записывает сообщения одним вызовом текущего writer.
+func (s *KafkaSender) writeMessages(ctx context.Context, msgs ...kafka.Message) error {
+	s.writerMutex.RLock()
+	err := s.writer.WriteMessages(ctx, msgs...)
+	s.writerMutex.RUnlock()
+
+	if err != nil {
+		zap.L().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// defaultKeyFn использует канонический ключ партиционирования события.
+func defaultKeyFn(message event.PageViewEvent) []byte {
+	return []byte(message.PartitionKey())
+}
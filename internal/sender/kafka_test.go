@@ -0,0 +1,576 @@
+package sender
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/serializer"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockWriter запоминает записанные сообщения и может вернуть заданную ошибку.
+type mockWriter struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+	calls    int
+	err      error
+	closed   bool
+	// release, если задан, блокирует запись до своего закрытия или отмены контекста
+	release chan struct{}
+	started chan struct{}
+}
+
+func (w *mockWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.release != nil {
+		close(w.started)
+		select {
+		case <-w.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.calls++
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *mockWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	return nil
+}
+
+func (w *mockWriter) Calls() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.calls
+}
+
+func (w *mockWriter) Closed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.closed
+}
+
+func (w *mockWriter) Messages() []kafka.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]kafka.Message(nil), w.messages...)
+}
+
+func testEvent() event.PageViewEvent {
+	return event.PageViewEvent{
+		PageID:       "page",
+		UserID:       "user",
+		ViewDuration: 1000,
+		Timestamp:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestKafkaSender_SendSync(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+
+	ev := testEvent()
+	assert.NoError(t, s.SendSync(t.Context(), ev))
+
+	messages := w.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, []byte(ev.PartitionKey()), messages[0].Key)
+}
+
+func TestKafkaSender_UseEventTime(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+	s.SetUseEventTime(true)
+
+	ev := testEvent()
+	assert.NoError(t, s.SendSync(t.Context(), ev))
+
+	messages := w.Messages()
+	assert.Len(t, messages, 1)
+	assert.True(t, ev.Timestamp.Equal(messages[0].Time))
+}
+
+func TestKafkaSender_BrokerTimeByDefault(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+
+	assert.NoError(t, s.SendSync(t.Context(), testEvent()))
+
+	messages := w.Messages()
+	assert.Len(t, messages, 1)
+	assert.True(t, messages[0].Time.IsZero())
+}
+
+func TestKafkaSender_WriteFnCallback(t *testing.T) {
+	expectedErr := errors.New("write failed")
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+
+	called := false
+	err := s.WriteFn(t.Context(), testEvent(), func(ctx context.Context, message event.PageViewEvent, err error) {
+		called = true
+		assert.NoError(t, err)
+	})
+	assert.NoError(t, err)
+	assert.True(t, called)
+
+	w.err = expectedErr
+	err = s.WriteFn(t.Context(), testEvent(), func(ctx context.Context, message event.PageViewEvent, err error) {
+		assert.Fail(t, "callback на ошибку вызывает Publisher")
+	})
+	assert.ErrorIs(t, err, expectedErr)
+}
+
+func TestKafkaSender_SetKeyFn(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+
+	ev := testEvent()
+	assert.NoError(t, s.SendSync(t.Context(), ev))
+
+	s.SetKeyFn(func(message event.PageViewEvent) []byte {
+		return []byte(message.PageID)
+	})
+	assert.NoError(t, s.SendSync(t.Context(), ev))
+
+	s.SetKeyFn(nil)
+	assert.NoError(t, s.SendSync(t.Context(), ev))
+
+	messages := w.Messages()
+	assert.Len(t, messages, 3)
+	assert.Equal(t, []byte(ev.PartitionKey()), messages[0].Key, "по умолчанию ключом служит PartitionKey")
+	assert.Equal(t, []byte(ev.PageID), messages[1].Key)
+	assert.Equal(t, []byte(ev.PartitionKey()), messages[2].Key, "nil восстанавливает ключ по умолчанию")
+}
+
+func TestKafkaSender_SetWriter(t *testing.T) {
+	old := &mockWriter{
+		release: make(chan struct{}),
+		started: make(chan struct{}),
+	}
+	s := NewKafkaSender(old)
+	assert.Same(t, old, s.Writer())
+
+	written := make(chan error)
+	go func() {
+		written <- s.SendSync(t.Context(), testEvent())
+	}()
+	<-old.started
+
+	replaced := make(chan error)
+	next := &mockWriter{}
+	go func() {
+		replaced <- s.SetWriter(next)
+	}()
+
+	select {
+	case <-replaced:
+		assert.Fail(t, "старый writer не должен закрываться до завершения записи")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.False(t, old.Closed())
+
+	close(old.release)
+	assert.NoError(t, <-written)
+	assert.NoError(t, <-replaced)
+	assert.True(t, old.Closed())
+	assert.Same(t, next, s.Writer())
+
+	assert.NoError(t, s.SendSync(t.Context(), testEvent()))
+	assert.Len(t, old.Messages(), 1)
+	assert.Len(t, next.Messages(), 1)
+}
+
+func TestKafkaSender_Headers(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+
+	ev := testEvent()
+	ev.Headers = map[string]string{
+		"tenant": "acme",
+		"source": "generator",
+	}
+	assert.NoError(t, s.SendSync(t.Context(), ev))
+
+	messages := w.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, []kafka.Header{
+		{Key: "source", Value: []byte("generator")},
+		{Key: "tenant", Value: []byte("acme")},
+	}, messages[0].Headers)
+	assert.NotContains(t, string(messages[0].Value), "tenant")
+}
+
+func TestFakeSender_RecordsSends(t *testing.T) {
+	s := NewFakeSender()
+
+	first, second := testEvent(), testEvent()
+	second.PageID = "second"
+
+	assert.NoError(t, s.SendSync(t.Context(), first))
+
+	var callbackErr error
+	called := false
+	assert.NoError(t, s.SendAsync(t.Context(), second, func(ctx context.Context, message event.PageViewEvent, err error) {
+		called = true
+		callbackErr = err
+	}))
+	assert.True(t, called)
+	assert.NoError(t, callbackErr)
+
+	assert.Equal(t, []event.PageViewEvent{first, second}, s.Sent())
+}
+
+func TestFakeSender_InjectedError(t *testing.T) {
+	expectedErr := errors.New("broker unavailable")
+	s := NewFakeSender()
+	s.SetError(expectedErr)
+
+	assert.ErrorIs(t, s.SendSync(t.Context(), testEvent()), expectedErr)
+
+	var callbackErr error
+	assert.NoError(t, s.SendAsync(t.Context(), testEvent(), func(ctx context.Context, message event.PageViewEvent, err error) {
+		callbackErr = err
+	}))
+	assert.ErrorIs(t, callbackErr, expectedErr)
+	assert.Empty(t, s.Sent())
+
+	s.SetError(nil)
+	assert.NoError(t, s.Close())
+	assert.ErrorIs(t, s.SendSync(t.Context(), testEvent()), ErrClosed)
+	assert.ErrorIs(t, s.SendAsync(t.Context(), testEvent(), nil), ErrClosed)
+}
+
+func TestKafkaSender_SyncTimeout(t *testing.T) {
+	w := &mockWriter{
+		release: make(chan struct{}),
+		started: make(chan struct{}),
+	}
+	defer close(w.release)
+
+	s := NewKafkaSender(w)
+	s.SetSyncTimeout(20 * time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		done <- s.SendSync(context.Background(), testEvent())
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("SendSync не ограничен таймаутом")
+	}
+	assert.Empty(t, w.Messages())
+}
+
+func TestKafkaSender_SendAsync_BatchByCount(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+	s.SetBatchTime(time.Hour)
+	s.SetBatchEventCount(3)
+
+	errs := make(chan error, 3)
+	for range 3 {
+		assert.NoError(t, s.SendAsync(t.Context(), testEvent(), func(ctx context.Context, message event.PageViewEvent, err error) {
+			errs <- err
+		}))
+	}
+
+	for range 3 {
+		select {
+		case err := <-errs:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("батч не отправлен по количеству событий")
+		}
+	}
+
+	assert.Equal(t, 1, w.Calls())
+	assert.Len(t, w.Messages(), 3)
+	assert.NoError(t, s.Close())
+}
+
+func TestKafkaSender_SendAsync_BatchByTime(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+	s.SetBatchEventCount(100)
+	s.SetBatchTime(20 * time.Millisecond)
+
+	errs := make(chan error, 2)
+	for range 2 {
+		assert.NoError(t, s.SendAsync(t.Context(), testEvent(), func(ctx context.Context, message event.PageViewEvent, err error) {
+			errs <- err
+		}))
+	}
+
+	for range 2 {
+		select {
+		case err := <-errs:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("батч не отправлен по таймеру")
+		}
+	}
+
+	assert.Equal(t, 1, w.Calls())
+	assert.Len(t, w.Messages(), 2)
+	assert.NoError(t, s.Close())
+}
+
+func TestKafkaSender_SendTombstone(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+
+	assert.NoError(t, s.SendTombstone(t.Context(), "user"))
+
+	messages := w.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, []byte("user"), messages[0].Key)
+	assert.Nil(t, messages[0].Value)
+}
+
+func TestKafkaSender_TombstoneContext(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+	s.SetBatchEventCount(1)
+	s.SetValidateBeforeSend(true)
+
+	tombstone := event.PageViewEvent{UserID: "user", PageID: "page"}
+	ctx := event.WithTombstone(t.Context())
+
+	assert.NoError(t, s.SendSync(ctx, tombstone))
+
+	errs := make(chan error, 1)
+	assert.NoError(t, s.SendAsync(ctx, tombstone, func(ctx context.Context, message event.PageViewEvent, err error) {
+		errs <- err
+	}))
+	assert.NoError(t, <-errs)
+
+	messages := w.Messages()
+	assert.Len(t, messages, 2)
+	for _, m := range messages {
+		assert.Equal(t, (*s.keyFn.Load())(tombstone), m.Key)
+		assert.Nil(t, m.Value)
+	}
+}
+
+func TestKafkaSender_MaxMessageBytes(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+	s.SetBatchEventCount(1)
+	s.SetMaxMessageBytes(16)
+
+	large := testEvent()
+	large.UserAgent = strings.Repeat("a", 1024)
+
+	assert.ErrorIs(t, s.SendSync(t.Context(), large), ErrMessageTooLarge)
+
+	errs := make(chan error, 1)
+	assert.NoError(t, s.SendAsync(t.Context(), large, func(ctx context.Context, message event.PageViewEvent, err error) {
+		errs <- err
+	}))
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+	case <-time.After(time.Second):
+		t.Fatal("callback не получил ошибку размера")
+	}
+
+	assert.Equal(t, 0, w.Calls())
+	assert.NoError(t, s.Close())
+}
+
+// upperSerializer тестовый сериализатор, записывающий UserID в верхнем регистре.
+type upperSerializer struct{}
+
+func (upperSerializer) Marshal(message event.PageViewEvent) ([]byte, error) {
+	return []byte(strings.ToUpper(message.UserID)), nil
+}
+
+func (upperSerializer) ContentType() string {
+	return "text/plain"
+}
+
+func TestKafkaSender_SetSerializer(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+	s.SetSerializer(upperSerializer{})
+
+	ev := testEvent()
+	assert.NoError(t, s.SendSync(t.Context(), ev))
+
+	s.SetSerializer(serializer.NewJSON[event.PageViewEvent]())
+	assert.NoError(t, s.SendSync(t.Context(), ev))
+
+	s.SetSerializer(nil)
+	assert.NoError(t, s.SendSync(t.Context(), ev))
+
+	messages := w.Messages()
+	assert.Len(t, messages, 3)
+
+	assert.Equal(t, []byte(strings.ToUpper(ev.UserID)), messages[0].Value)
+	assert.Equal(t, []kafka.Header{{Key: serializer.ContentTypeHeader, Value: []byte("text/plain")}}, messages[0].Headers)
+
+	expected, err := ev.Bytes()
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(messages[1].Value))
+	assert.Equal(t, []kafka.Header{{Key: serializer.ContentTypeHeader, Value: []byte(serializer.ContentTypeJSON)}}, messages[1].Headers)
+
+	assert.Equal(t, expected, messages[2].Value)
+	assert.Empty(t, messages[2].Headers)
+}
+
+func TestKafkaSender_SendAsync_CallbackContext(t *testing.T) {
+	type ctxKey struct{}
+
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+	s.SetBatchTime(time.Hour)
+	s.SetBatchEventCount(3)
+
+	values := make(chan [2]string, 3)
+	for _, id := range []string{"a", "b", "c"} {
+		ev := testEvent()
+		ev.PageID = id
+		ctx := context.WithValue(t.Context(), ctxKey{}, id)
+		assert.NoError(t, s.SendAsync(ctx, ev, func(ctx context.Context, message event.PageViewEvent, err error) {
+			v, _ := ctx.Value(ctxKey{}).(string)
+			values <- [2]string{message.PageID, v}
+		}))
+	}
+
+	for range 3 {
+		select {
+		case v := <-values:
+			assert.Equal(t, v[0], v[1], "callback получил контекст другого сообщения")
+		case <-time.After(time.Second):
+			t.Fatal("батч не отправлен")
+		}
+	}
+
+	assert.Equal(t, 1, w.Calls())
+	assert.NoError(t, s.Close())
+}
+
+func testKafkaConfig() KafkaConfig {
+	return KafkaConfig{
+		Brokers:      []string{"kafka-1:9092", "kafka-2:9092"},
+		Topic:        "events",
+		WriteTimeout: 5 * time.Second,
+		RequiredAcks: kafka.RequireAll,
+		Balancer:     &kafka.Hash{},
+	}
+}
+
+func TestNewKafkaWriter_AppliesConfig(t *testing.T) {
+	cfg := testKafkaConfig()
+
+	w, ok := NewKafkaWriter(cfg).(*kafka.Writer)
+	assert.True(t, ok)
+
+	assert.Equal(t, "kafka-1:9092,kafka-2:9092", w.Addr.String())
+	assert.Equal(t, cfg.Topic, w.Topic)
+	assert.Equal(t, cfg.WriteTimeout, w.WriteTimeout)
+	assert.Equal(t, kafka.RequireAll, w.RequiredAcks)
+	assert.Same(t, cfg.Balancer, w.Balancer)
+}
+
+func TestNewKafkaSenderFromConfig(t *testing.T) {
+	cfg := testKafkaConfig()
+	w := &mockWriter{}
+
+	var got KafkaConfig
+	s, err := NewKafkaSenderFromConfig(cfg, func(cfg KafkaConfig) KafkaWriter {
+		got = cfg
+		return w
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, cfg, got)
+	assert.Same(t, w, s.Writer())
+
+	assert.NoError(t, s.SendSync(t.Context(), testEvent()))
+	assert.Len(t, w.Messages(), 1)
+}
+
+func TestKafkaConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *KafkaConfig)
+		err    error
+	}{
+		{"no brokers", func(cfg *KafkaConfig) { cfg.Brokers = nil }, ErrNoBrokers},
+		{"empty broker", func(cfg *KafkaConfig) { cfg.Brokers = []string{""} }, ErrNoBrokers},
+		{"empty topic", func(cfg *KafkaConfig) { cfg.Topic = "" }, ErrEmptyTopic},
+		{"negative timeout", func(cfg *KafkaConfig) { cfg.WriteTimeout = -time.Second }, ErrInvalidWriteTimeout},
+		{"invalid acks", func(cfg *KafkaConfig) { cfg.RequiredAcks = 2 }, ErrInvalidRequiredAcks},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testKafkaConfig()
+			tt.modify(&cfg)
+
+			_, err := NewKafkaSenderFromConfig(cfg, func(cfg KafkaConfig) KafkaWriter {
+				t.Fatal("writer must not be created for invalid config")
+				return nil
+			})
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestKafkaSender_ValidateBeforeSend(t *testing.T) {
+	w := &mockWriter{}
+	s := NewKafkaSender(w)
+	s.SetBatchEventCount(1)
+	s.SetValidateBeforeSend(true)
+
+	invalid := testEvent()
+	invalid.PageID = ""
+
+	err := s.SendSync(t.Context(), invalid)
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	assert.ErrorIs(t, err, event.ErrEmptyPageID)
+
+	errs := make(chan error, 1)
+	assert.NoError(t, s.SendAsync(t.Context(), invalid, func(ctx context.Context, message event.PageViewEvent, err error) {
+		errs <- err
+	}))
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrInvalidEvent)
+	case <-time.After(time.Second):
+		t.Fatal("callback не получил ошибку валидации")
+	}
+
+	assert.Equal(t, 0, w.Calls())
+
+	s.SetValidateBeforeSend(false)
+	assert.NoError(t, s.SendSync(t.Context(), invalid))
+	assert.Equal(t, 1, w.Calls())
+	assert.NoError(t, s.Close())
+}
@@ -0,0 +1,30 @@
+package sender
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/publisher"
+	"ay-events-generator/internal/serializer"
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaWriter абстракция над *kafka.Writer, позволяющая подменять его в тестах.
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Sender отправляет события во внешнюю систему.
+// SendAsync сообщает результат записи через callback.
+type Sender interface {
+	SendSync(ctx context.Context, message event.PageViewEvent) error
+	SendAsync(ctx context.Context, message event.PageViewEvent, callback publisher.Callback[event.PageViewEvent]) error
+	Close() error
+}
+
+// KeyFn извлекает ключ Kafka-сообщения из события.
+type KeyFn = func(message event.PageViewEvent) []byte
+
+// Serializer сериализует значение Kafka-сообщения.
+type Serializer = serializer.Serializer[event.PageViewEvent]
@@ -0,0 +1,8 @@
+package serializer
+
+const (
+	// ContentTypeJSON тип содержимого JSON сериализатора
+	ContentTypeJSON = "application/json"
+	// ContentTypeHeader заголовок сообщения с типом содержимого
+	ContentTypeHeader = "content-type"
+)
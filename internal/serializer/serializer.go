@@ -0,0 +1,41 @@
+package serializer
+
+import "encoding/json"
+
+// Serializer преобразует сообщение в байты для записи в sink.
+// ContentType описывает формат результата (например, "application/json").
+type Serializer[T any] interface {
+	Marshal(message T) ([]byte, error)
+	ContentType() string
+}
+
+// Codec сериализатор, умеющий восстанавливать сообщение.
+// Нужен для sink, читающих записанные данные обратно, например спула.
+type Codec[T any] interface {
+	Serializer[T]
+	Unmarshal(data []byte) (T, error)
+}
+
+// JSON сериализует сообщения через encoding/json.
+type JSON[T any] struct{}
+
+var _ Codec[any] = JSON[any]{}
+
+// NewJSON создает JSON сериализатор.
+func NewJSON[T any]() JSON[T] {
+	return JSON[T]{}
+}
+
+func (JSON[T]) Marshal(message T) ([]byte, error) {
+	return json.Marshal(message)
+}
+
+func (JSON[T]) Unmarshal(data []byte) (T, error) {
+	var message T
+	err := json.Unmarshal(data, &message)
+	return message, err
+}
+
+func (JSON[T]) ContentType() string {
+	return ContentTypeJSON
+}
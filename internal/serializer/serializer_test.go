@@ -0,0 +1,31 @@
+package serializer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON_Marshal(t *testing.T) {
+	type message struct {
+		ID string `json:"id"`
+	}
+
+	s := NewJSON[message]()
+
+	b, err := s.Marshal(message{ID: "1"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1"}`, string(b))
+	assert.Equal(t, ContentTypeJSON, s.ContentType())
+
+	decoded, err := s.Unmarshal(b)
+	assert.NoError(t, err)
+	assert.Equal(t, message{ID: "1"}, decoded)
+}
+
+func TestJSON_MarshalError(t *testing.T) {
+	s := NewJSON[chan int]()
+
+	_, err := s.Marshal(make(chan int))
+	assert.Error(t, err)
+}
@@ -0,0 +1,7 @@
+package sink
+
+import "errors"
+
+var (
+	ErrSpoolRecordNewline = errors.New("spool record contains newline")
+)
@@ -0,0 +1,111 @@
+package sink
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/publisher"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemorySink потокобезопасное хранилище событий в памяти для интеграционных тестов.
+// Хранит не более capacity последних событий (кольцевой буфер)
+// и может имитировать задержку и ошибки записи.
+type MemorySink struct {
+	m       sync.Mutex
+	events  []event.PageViewEvent
+	next    int
+	full    bool
+	latency time.Duration
+	err     error
+}
+
+// NewMemorySink создает хранилище на capacity событий.
+// При capacity <= 0 используется емкость 1.
+func NewMemorySink(capacity int) *MemorySink {
+	if capacity <= 0 {
+		capacity = 1
+	}
+
+	return &MemorySink{
+		events: make([]event.PageViewEvent, capacity),
+	}
+}
+
+// SetLatency задает задержку каждой записи.
+func (s *MemorySink) SetLatency(d time.Duration) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.latency = d
+}
+
+// SetError задает ошибку, возвращаемую каждой записью. nil отключает ошибку.
+func (s *MemorySink) SetError(err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.err = err
+}
+
+// WriteFn сохраняет событие и вызывает callback при успехе.
+// Совместима с publisher.WriteFn: при ошибке callback вызывает воркер Publisher.
+func (s *MemorySink) WriteFn(ctx context.Context, message event.PageViewEvent, callback publisher.Callback[event.PageViewEvent]) error {
+	s.m.Lock()
+	latency, err := s.latency, s.err
+	s.m.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.m.Lock()
+	s.events[s.next] = message
+	s.next = (s.next + 1) % len(s.events)
+	if s.next == 0 {
+		s.full = true
+	}
+	s.m.Unlock()
+
+	if callback != nil {
+		callback(ctx, message, nil)
+	}
+
+	return nil
+}
+
+// Events возвращает сохраненные события от самого старого к самому новому.
+func (s *MemorySink) Events() []event.PageViewEvent {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if !s.full {
+		events := make([]event.PageViewEvent, s.next)
+		copy(events, s.events[:s.next])
+		return events
+	}
+
+	events := make([]event.PageViewEvent, 0, len(s.events))
+	events = append(events, s.events[s.next:]...)
+	events = append(events, s.events[:s.next]...)
+	return events
+}
+
+// Len возвращает количество сохраненных событий.
+func (s *MemorySink) Len() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.full {
+		return len(s.events)
+	}
+	return s.next
+}
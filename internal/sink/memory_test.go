@@ -0,0 +1,74 @@
+package sink
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/generator"
+	"ay-events-generator/internal/publisher"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorySink_Pipeline(t *testing.T) {
+	const want = 20
+
+	s := NewMemorySink(100)
+	s.SetLatency(time.Millisecond)
+
+	p, err := publisher.NewPublisher[event.PageViewEvent](t.Context(), s.WriteFn, 4, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := generator.NewEventGenerator(generator.WithMode(generator.PickLoadMode), generator.WithInvalidRate(0))
+	events := g.Events()
+
+	go func() {
+		for e := range events {
+			_ = p.SendAsync(t.Context(), e.Event, nil)
+		}
+	}()
+
+	assert.Eventually(t, func() bool {
+		return s.Len() >= want
+	}, 5*time.Second, 10*time.Millisecond)
+
+	g.Close()
+	assert.NoError(t, p.Drain(t.Context()))
+	assert.NoError(t, p.Close())
+
+	for _, e := range s.Events() {
+		assert.NotEmpty(t, e.PageID)
+	}
+}
+
+func TestMemorySink_RingBuffer(t *testing.T) {
+	s := NewMemorySink(3)
+
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		assert.NoError(t, s.WriteFn(t.Context(), event.PageViewEvent{PageID: id}, nil))
+	}
+
+	assert.Equal(t, 3, s.Len())
+
+	var ids []string
+	for _, e := range s.Events() {
+		ids = append(ids, e.PageID)
+	}
+	assert.Equal(t, []string{"3", "4", "5"}, ids)
+}
+
+func TestMemorySink_Error(t *testing.T) {
+	expectedErr := errors.New("sink unavailable")
+	s := NewMemorySink(3)
+	s.SetError(expectedErr)
+
+	err := s.WriteFn(t.Context(), event.PageViewEvent{PageID: "1"}, func(ctx context.Context, message event.PageViewEvent, err error) {
+		assert.Fail(t, "callback на ошибку вызывает Publisher")
+	})
+	assert.ErrorIs(t, err, expectedErr)
+	assert.Equal(t, 0, s.Len())
+}
@@ -0,0 +1,20 @@
+package sink
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/publisher"
+	"context"
+)
+
+// SplitByValidity возвращает WriteFn, направляющую события в valid или invalid
+// в зависимости от результата event.Validate. Позволяет, например,
+// отправлять некорректные события в отдельный карантинный топик.
+func SplitByValidity(valid, invalid publisher.WriteFn[event.PageViewEvent]) publisher.WriteFn[event.PageViewEvent] {
+	return func(ctx context.Context, message event.PageViewEvent, callback publisher.Callback[event.PageViewEvent]) error {
+		if err := message.Validate(); err != nil {
+			return invalid(ctx, message, callback)
+		}
+
+		return valid(ctx, message, callback)
+	}
+}
@@ -0,0 +1,40 @@
+package sink
+
+import (
+	"ay-events-generator/internal/event"
+	"ay-events-generator/internal/publisher"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingWriteFn возвращает WriteFn, запоминающую PageID записанных событий.
+func recordingWriteFn(out *[]string) publisher.WriteFn[event.PageViewEvent] {
+	return func(ctx context.Context, message event.PageViewEvent, callback publisher.Callback[event.PageViewEvent]) error {
+		*out = append(*out, message.PageID)
+		return nil
+	}
+}
+
+func TestSplitByValidity(t *testing.T) {
+	var valid, invalid []string
+
+	write := SplitByValidity(recordingWriteFn(&valid), recordingWriteFn(&invalid))
+
+	events := []event.PageViewEvent{
+		{PageID: "ok-1", UserID: "user", ViewDuration: 100, Timestamp: time.Now()},
+		{PageID: "", UserID: "user", ViewDuration: 100, Timestamp: time.Now()},
+		{PageID: "negative", UserID: "user", ViewDuration: -1, Timestamp: time.Now()},
+		{PageID: "bad-utf8", UserID: "user", ViewDuration: 100, UserAgent: string([]byte{0xff}), Timestamp: time.Now()},
+		{PageID: "ok-2", UserID: "user", ViewDuration: 100, Timestamp: time.Now()},
+	}
+
+	for _, e := range events {
+		assert.NoError(t, write(t.Context(), e, nil))
+	}
+
+	assert.Equal(t, []string{"ok-1", "ok-2"}, valid)
+	assert.Equal(t, []string{"", "negative", "bad-utf8"}, invalid)
+}
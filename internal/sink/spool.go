@@ -0,0 +1,262 @@
+package sink
+
+import (
+	"ay-events-generator/internal/publisher"
+	"ay-events-generator/internal/serializer"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SpoolWriter оборачивает WriteFn: при ошибке записи сообщение сохраняется
+// в локальный файл (JSON lines), а фоновая горутина периодически повторяет
+// доставку сохраненных сообщений. Пока спул не пуст, новые сообщения также
+// пишутся в него, чтобы сохранить порядок доставки.
+type SpoolWriter[T any] struct {
+	inner    publisher.WriteFn[T]
+	interval time.Duration
+	codec    serializer.Codec[T]
+
+	m       sync.Mutex
+	file    *os.File
+	pending int
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// SpoolOption настраивает SpoolWriter при создании.
+type SpoolOption[T any] func(*SpoolWriter[T])
+
+// WithSpoolCodec задает формат записей спула. По умолчанию используется
+// serializer.JSON. Записи хранятся построчно, поэтому сериализованное
+// сообщение не должно содержать перевод строки.
+func WithSpoolCodec[T any](codec serializer.Codec[T]) SpoolOption[T] {
+	return func(s *SpoolWriter[T]) {
+		s.codec = codec
+	}
+}
+
+// NewSpoolWriter открывает (или создает) файл спула по пути path и запускает
+// повтор доставки с периодом replayInterval. Сообщения, оставшиеся в файле
+// после предыдущего запуска, будут доставлены первыми.
+func NewSpoolWriter[T any](inner publisher.WriteFn[T], path string, replayInterval time.Duration, opts ...SpoolOption[T]) (*SpoolWriter[T], error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return nil, err
+	}
+
+	s := &SpoolWriter[T]{
+		inner:    inner,
+		interval: replayInterval,
+		codec:    serializer.NewJSON[T](),
+		file:     file,
+		stopCh:   make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	lines, err := s.readLines()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	s.pending = len(lines)
+
+	s.wg.Add(1)
+	go s.replayProcess()
+
+	return s, nil
+}
+
+// WriteFn записывает сообщение во внутреннюю WriteFn, а при ошибке — в спул.
+// Сообщение, сохраненное в спул, считается принятым: callback вызывается без ошибки.
+// Если не удалось записать и в спул, callback не вызывается, а возвращается
+// ошибка спула вместе с ошибкой внутренней записи.
+// Внутренняя запись выполняется без блокировки спула, поэтому
+// параллельные вызовы не выстраиваются в очередь друг за другом.
+func (s *SpoolWriter[T]) WriteFn(ctx context.Context, message T, callback publisher.Callback[T]) error {
+	s.m.Lock()
+	spooling := s.pending > 0
+	s.m.Unlock()
+
+	var innerErr error
+	if !spooling {
+		// callback вызывается здесь ровно один раз, поэтому внутренней записи он не передается
+		innerErr = s.inner(ctx, message, nil)
+		if innerErr == nil {
+			if callback != nil {
+				callback(ctx, message, nil)
+			}
+			return nil
+		}
+		zap.L().Error(innerErr.Error())
+	}
+
+	s.m.Lock()
+	err := s.append(message)
+	s.m.Unlock()
+
+	if err != nil {
+		return errors.Join(innerErr, err)
+	}
+
+	if callback != nil {
+		callback(ctx, message, nil)
+	}
+
+	return nil
+}
+
+// Pending возвращает количество сообщений, ожидающих повторной доставки.
+func (s *SpoolWriter[T]) Pending() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.pending
+}
+
+// Close останавливает повтор доставки и закрывает файл спула.
+// Недоставленные сообщения остаются в файле.
+func (s *SpoolWriter[T]) Close() error {
+	close(s.stopCh)
+	s.wg.Wait()
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.file.Close()
+}
+
+// replayProcess периодически повторяет доставку сообщений из спула.
+func (s *SpoolWriter[T]) replayProcess() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.replay()
+		}
+	}
+}
+
+// replay доставляет сообщения из спула по порядку до первой ошибки
+// и удаляет доставленные сообщения из файла. Спул блокируется только
+// на время чтения и перезаписи файла; сообщения, добавленные во время
+// доставки, остаются в спуле после доставленных.
+func (s *SpoolWriter[T]) replay() {
+	s.m.Lock()
+	if s.pending == 0 {
+		s.m.Unlock()
+		return
+	}
+	lines, err := s.readLines()
+	s.m.Unlock()
+	if err != nil {
+		return
+	}
+
+	delivered := 0
+	for _, line := range lines {
+		message, err := s.codec.Unmarshal(line)
+		if err != nil {
+			zap.L().Error(err.Error())
+			delivered++
+			continue
+		}
+
+		if err = s.inner(context.Background(), message, nil); err != nil {
+			zap.L().Error(err.Error())
+			break
+		}
+		delivered++
+	}
+
+	if delivered == 0 {
+		return
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// перечитываем файл, чтобы сохранить сообщения, добавленные во время доставки
+	lines, err = s.readLines()
+	if err != nil {
+		return
+	}
+
+	if err = s.file.Truncate(0); err != nil {
+		zap.L().Error(err.Error())
+		return
+	}
+
+	s.pending = 0
+	for _, line := range lines[delivered:] {
+		if _, err = s.file.Write(append(line, '\n')); err != nil {
+			zap.L().Error(err.Error())
+			return
+		}
+		s.pending++
+	}
+}
+
+// append дописывает сообщение в конец спула.
+func (s *SpoolWriter[T]) append(message T) error {
+	b, err := s.codec.Marshal(message)
+	if err != nil {
+		zap.L().Error(err.Error())
+		return err
+	}
+
+	if bytes.IndexByte(b, '\n') >= 0 {
+		zap.L().Error(ErrSpoolRecordNewline.Error())
+		return ErrSpoolRecordNewline
+	}
+
+	if _, err = s.file.Write(append(b, '\n')); err != nil {
+		zap.L().Error(err.Error())
+		return err
+	}
+
+	s.pending++
+	return nil
+}
+
+// readLines читает все записи спула.
+func (s *SpoolWriter[T]) readLines() ([][]byte, error) {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		zap.L().Error(err.Error())
+		return nil, err
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(s.file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, bytes.Clone(line))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		zap.L().Error(err.Error())
+		return nil, err
+	}
+
+	return lines, nil
+}
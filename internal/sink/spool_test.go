@@ -0,0 +1,239 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpoolWriter_ReplaysAfterOutage(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		delivered []int
+		down      atomic.Bool
+	)
+
+	inner := func(ctx context.Context, message int, callback func(context.Context, int, error)) error {
+		if down.Load() {
+			return errors.New("kafka is down")
+		}
+		mu.Lock()
+		delivered = append(delivered, message)
+		mu.Unlock()
+		return nil
+	}
+
+	s, err := NewSpoolWriter[int](inner, filepath.Join(t.TempDir(), "spool.wal"), 10*time.Millisecond)
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, s.Close())
+	}()
+
+	assert.NoError(t, s.WriteFn(t.Context(), 0, nil))
+
+	down.Store(true)
+	var accepted atomic.Int32
+	for i := 1; i <= 5; i++ {
+		assert.NoError(t, s.WriteFn(t.Context(), i, func(ctx context.Context, message int, err error) {
+			assert.NoError(t, err)
+			accepted.Add(1)
+		}))
+	}
+	assert.Equal(t, int32(5), accepted.Load())
+	assert.Equal(t, 5, s.Pending())
+
+	down.Store(false)
+	// новое сообщение встает в очередь за спулом, чтобы не нарушить порядок
+	assert.NoError(t, s.WriteFn(t.Context(), 6, nil))
+
+	assert.Eventually(t, func() bool {
+		return s.Pending() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, delivered)
+}
+
+func TestSpoolWriter_ResumesFromExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.wal")
+
+	failing := func(ctx context.Context, message int, callback func(context.Context, int, error)) error {
+		return errors.New("kafka is down")
+	}
+
+	s, err := NewSpoolWriter[int](failing, path, time.Hour)
+	assert.NoError(t, err)
+	assert.NoError(t, s.WriteFn(t.Context(), 1, nil))
+	assert.NoError(t, s.WriteFn(t.Context(), 2, nil))
+	assert.NoError(t, s.Close())
+
+	var (
+		mu        sync.Mutex
+		delivered []int
+	)
+	inner := func(ctx context.Context, message int, callback func(context.Context, int, error)) error {
+		mu.Lock()
+		delivered = append(delivered, message)
+		mu.Unlock()
+		return nil
+	}
+
+	s, err = NewSpoolWriter[int](inner, path, 10*time.Millisecond)
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, s.Close())
+	}()
+
+	assert.Eventually(t, func() bool {
+		return s.Pending() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{1, 2}, delivered)
+}
+
+func TestSpoolWriter_CallbackOnceAndInnerError(t *testing.T) {
+	errDown := errors.New("kafka is down")
+
+	// внутренняя запись сама сообщает об ошибке через callback
+	inner := func(ctx context.Context, message any, callback func(context.Context, any, error)) error {
+		if callback != nil {
+			callback(ctx, message, errDown)
+		}
+		return errDown
+	}
+
+	s, err := NewSpoolWriter[any](inner, filepath.Join(t.TempDir(), "spool.wal"), time.Hour)
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, s.Close())
+	}()
+
+	var calls []error
+	assert.NoError(t, s.WriteFn(t.Context(), 1, func(ctx context.Context, message any, err error) {
+		calls = append(calls, err)
+	}))
+	assert.Equal(t, []error{nil}, calls)
+
+	// сообщение, которое нельзя сохранить в спул, возвращает и ошибку внутренней записи
+	s.m.Lock()
+	s.pending = 0
+	s.m.Unlock()
+
+	calls = nil
+	err = s.WriteFn(t.Context(), func() {}, func(ctx context.Context, message any, err error) {
+		calls = append(calls, err)
+	})
+	assert.ErrorIs(t, err, errDown)
+	assert.Empty(t, calls)
+}
+
+func TestSpoolWriter_ConcurrentWrites(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	inner := func(ctx context.Context, message int, callback func(context.Context, int, error)) error {
+		if message == 1 {
+			close(started)
+			<-release
+		}
+		return nil
+	}
+
+	s, err := NewSpoolWriter[int](inner, filepath.Join(t.TempDir(), "spool.wal"), time.Hour)
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, s.Close())
+	}()
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- s.WriteFn(t.Context(), 1, nil)
+	}()
+	<-started
+
+	// медленная запись одного сообщения не блокирует остальные
+	written := make(chan error, 1)
+	go func() {
+		written <- s.WriteFn(t.Context(), 2, nil)
+	}()
+
+	select {
+	case err := <-written:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("WriteFn заблокирован параллельной записью")
+	}
+
+	close(release)
+	assert.NoError(t, <-blocked)
+}
+
+// prefixCodec тестовый формат спула: число с префиксом.
+type prefixCodec struct{}
+
+func (prefixCodec) Marshal(message int) ([]byte, error) {
+	return []byte("n=" + strconv.Itoa(message)), nil
+}
+
+func (prefixCodec) Unmarshal(data []byte) (int, error) {
+	return strconv.Atoi(strings.TrimPrefix(string(data), "n="))
+}
+
+func (prefixCodec) ContentType() string {
+	return "text/plain"
+}
+
+func TestSpoolWriter_Codec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.wal")
+
+	var (
+		mu        sync.Mutex
+		delivered []int
+		down      atomic.Bool
+	)
+	down.Store(true)
+
+	inner := func(ctx context.Context, message int, callback func(context.Context, int, error)) error {
+		if down.Load() {
+			return errors.New("kafka is down")
+		}
+		mu.Lock()
+		delivered = append(delivered, message)
+		mu.Unlock()
+		return nil
+	}
+
+	s, err := NewSpoolWriter[int](inner, path, 10*time.Millisecond, WithSpoolCodec[int](prefixCodec{}))
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, s.Close())
+	}()
+
+	assert.NoError(t, s.WriteFn(t.Context(), 1, nil))
+	assert.NoError(t, s.WriteFn(t.Context(), 2, nil))
+
+	// записи спула сериализованы заданным форматом
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "n=1\nn=2\n", string(data))
+
+	down.Store(false)
+	assert.Eventually(t, func() bool {
+		return s.Pending() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{1, 2}, delivered)
+}